@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gonutz/ld41/game"
+	"github.com/gonutz/ld41/mathgen"
+	"github.com/gonutz/prototype/draw"
+)
+
+const (
+	achievementsFilePrefix = "brainless_jogging_achievements"
+	toastTime              = 3 * time.Second
+)
+
+type achievement struct {
+	id          string // used in the file, never change it
+	name        string
+	description string
+	goal        int // the progress it takes to unlock the achievement
+}
+
+var allAchievements = []achievement{
+	{"clean-kills", "Sharpshooter", "Kill 10 zombies without a miss", 10},
+	{"divisions", "Divide and Conquer", "Solve 50 divisions", 50},
+	{"survive", "Survivor", "Survive 3 minutes", 180},
+	{"edge", "Close Call", "Hit a zombie at the edge of the screen", 1},
+	{"centurion", "Centurion", "Kill C zombies in one game", 100},
+}
+
+// achievementTracker listens to the events of a game and unlocks the
+// achievements of a profile. The progress is kept across games.
+type achievementTracker struct {
+	profile    string
+	sprint     bool
+	progress   map[string]int
+	cleanKills map[int]int // kills since the last miss of each player
+	kills      int
+	toasts     []string // unlocked achievements that are yet to be shown
+	toastLife  int
+}
+
+func loadAchievements(profile string) *achievementTracker {
+	t := &achievementTracker{
+		profile:  profile,
+		progress: make(map[string]int),
+	}
+	for _, line := range strings.Split(loadText(t.fileName()), "\n") {
+		id, progress, ok := strings.Cut(line, " ")
+		if n, err := strconv.Atoi(progress); ok && err == nil {
+			t.progress[id] = n
+		}
+	}
+	return t
+}
+
+func (t *achievementTracker) fileName() string {
+	return profileFileName(achievementsFilePrefix, t.profile)
+}
+
+func (t *achievementTracker) save() {
+	var text string
+	for _, a := range allAchievements {
+		if n := t.progress[a.id]; n > 0 {
+			text += fmt.Sprintf("%s %d\n", a.id, n)
+		}
+	}
+	saveText(t.fileName(), text)
+}
+
+// startGame resets what is counted per game.
+func (t *achievementTracker) startGame(settings gameSettings) {
+	t.sprint = settings.sprint != 0
+	t.cleanKills = make(map[int]int)
+	t.kills = 0
+	t.toasts = nil
+	t.toastLife = 0
+}
+
+func (t *achievementTracker) handle(e game.Event) {
+	switch e := e.(type) {
+	case game.AnswerSubmitted:
+		if !e.Correct {
+			t.cleanKills[e.Player] = 0
+		} else if e.Assignment.Op == mathgen.Divide {
+			t.reach("divisions", t.progress["divisions"]+1)
+		}
+	case game.ZombieKilled:
+		t.cleanKills[e.Player]++
+		t.reach("clean-kills", t.cleanKills[e.Player])
+		t.kills++
+		t.reach("centurion", t.kills)
+		if z := e.Zombie; z.X < 0 || z.X+game.ZombieW > windowW {
+			t.reach("edge", 1)
+		}
+	case game.SecondPassed:
+		// zombies cannot eat you in a sprint
+		if !t.sprint {
+			t.reach("survive", e.Seconds)
+		}
+	}
+}
+
+// reach sets the progress of the achievement if it is better than before and
+// shows a toast once the goal is reached.
+func (t *achievementTracker) reach(id string, progress int) {
+	old := t.progress[id]
+	if progress <= old {
+		return
+	}
+	t.progress[id] = progress
+	for _, a := range allAchievements {
+		if a.id == id && old < a.goal && progress >= a.goal {
+			t.toasts = append(t.toasts, "Achievement unlocked: "+a.name)
+		}
+	}
+}
+
+func (t *achievementTracker) unlocked(a achievement) bool {
+	return t.progress[a.id] >= a.goal
+}
+
+// drawToast shows the unlocked achievements one after the other.
+func (t *achievementTracker) drawToast(window draw.Window, y int) {
+	if len(t.toasts) == 0 {
+		return
+	}
+	if t.toastLife == 0 {
+		t.toastLife = frames(toastTime)
+	}
+	const textScale = 2
+	w, h := window.GetScaledTextSize(t.toasts[0], textScale)
+	x := (windowW - w) / 2
+	window.FillRect(x-15, y-8, w+30, h+16, draw.RGBA(0, 0, 0, 0.7))
+	window.DrawRect(x-15, y-8, w+30, h+16, draw.Yellow)
+	window.DrawScaledText(t.toasts[0], x, y, textScale, draw.Yellow)
+	t.toastLife--
+	if t.toastLife == 0 {
+		t.toasts = t.toasts[1:]
+	}
+}
+
+// achievementsState lists all achievements of the current profile.
+type achievementsState struct {
+	tracker *achievementTracker
+}
+
+func (s *achievementsState) enter(state) {
+	s.tracker = loadAchievements(currentProfile)
+}
+
+func (*achievementsState) leave() {}
+
+func (s *achievementsState) update(window draw.Window) state {
+	if window.WasKeyPressed(draw.KeyEscape) ||
+		window.WasKeyPressed(draw.KeyEnter) ||
+		window.WasKeyPressed(draw.KeyNumEnter) {
+		return menu
+	}
+	const (
+		titleScale = 3
+		nameScale  = 2.5
+		lineScale  = 1.5
+	)
+	title := "Achievements of " + currentProfile
+	w, h := window.GetScaledTextSize(title, titleScale)
+	window.DrawScaledText(title, (windowW-w)/2, 30, titleScale, draw.White)
+	y := 30 + h + 30
+	for _, a := range allAchievements {
+		color, status := draw.Gray, fmt.Sprintf("%d/%d", s.tracker.progress[a.id], a.goal)
+		if s.tracker.unlocked(a) {
+			color, status = draw.Yellow, "unlocked"
+		}
+		if a.goal == 1 && !s.tracker.unlocked(a) {
+			status = "locked"
+		}
+		_, nameH := window.GetScaledTextSize(a.name, nameScale)
+		window.DrawScaledText(a.name, 150, y, nameScale, color)
+		statusW, _ := window.GetScaledTextSize(status, nameScale)
+		window.DrawScaledText(status, windowW-150-statusW, y, nameScale, color)
+		_, lineH := window.GetScaledTextSize(a.description, lineScale)
+		window.DrawScaledText(a.description, 150, y+nameH, lineScale, draw.LightGray)
+		y += nameH + lineH + 15
+	}
+	return achievements
+}
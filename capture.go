@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"path/filepath"
+	"time"
+
+	"github.com/gonutz/ld41/offscreen"
+	"github.com/gonutz/prototype/draw"
+)
+
+// The screenshot key saves the current frame as a PNG, the record key starts
+// and stops recording an animated GIF. The window cannot be read back, so the
+// captured frames are drawn a second time by the offscreen renderer. The files
+// go to the captureFolder on the desktop and are downloaded in the browser.
+const (
+	screenshotKey      = draw.KeyF12
+	recordKey          = draw.KeyF9
+	captureFolder      = "brainless_jogging_captures"
+	recordInterval     = 50 * time.Millisecond // the video has 20 frames per second
+	recordShrink       = 2                     // the video is half the size of the window
+	maxRecording       = 20 * time.Second
+	captureMessageTime = 3 * time.Second
+)
+
+// Recording and messages go by the time that passed, not by the frames, which
+// come at the monitor's refresh rate.
+type screenCapture struct {
+	offscreen    *offscreen.Window
+	capturing    bool // this frame is drawn offscreen, too
+	screenshot   bool
+	recording    *offscreen.Recording
+	recordStart  time.Time
+	recorded     time.Duration // since the recording started, as of this frame
+	saved        chan string
+	message      string
+	messageUntil time.Time
+}
+
+// window returns the window that the state draws the frame to. While this
+// frame is captured, everything is drawn offscreen as well.
+func (c *screenCapture) window(window draw.Window) draw.Window {
+	if window.WasKeyPressed(screenshotKey) {
+		c.screenshot = true
+	}
+	if window.WasKeyPressed(recordKey) {
+		if c.recording == nil {
+			c.recording = offscreen.NewRecording(int(recordInterval/(10*time.Millisecond)), recordShrink)
+			c.recordStart = timeNow()
+		} else {
+			c.stopRecording()
+		}
+	}
+	if c.recording != nil {
+		c.recorded = timeNow().Sub(c.recordStart)
+	}
+	c.capturing = c.screenshot || c.recording != nil && c.videoFrameDue()
+	if !c.capturing {
+		return window
+	}
+	if c.offscreen == nil {
+		c.offscreen = offscreen.New(windowW, windowH)
+	}
+	c.offscreen.Frame()
+	return mirror{Window: window, offscreen: c.offscreen}
+}
+
+// finish saves what was captured in this frame and draws the recording status
+// over it, which is not captured.
+func (c *screenCapture) finish(window draw.Window) {
+	if c.capturing && c.screenshot {
+		c.screenshot = false
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, c.offscreen.Image()); err == nil {
+			c.save(captureName(".png"), buf.Bytes())
+		}
+	}
+	if c.capturing && c.recording != nil {
+		// if the frames come slower than the video's, the frame fills the
+		// gap so the video plays at the right speed
+		for c.videoFrameDue() {
+			c.recording.Add(c.offscreen.Image())
+		}
+	}
+	if c.recording != nil && c.recorded >= maxRecording {
+		c.stopRecording()
+	}
+
+	select {
+	case msg := <-c.saved:
+		c.showMessage(msg)
+	default:
+	}
+	if c.recording != nil {
+		const size = 20
+		if c.recorded/(time.Second/2)%2 == 0 {
+			window.FillEllipse(windowW-2*size, size, size, size, draw.Red)
+		}
+		text := "REC " + clock(int(c.recorded/time.Millisecond), 1000)
+		w, _ := window.GetScaledTextSize(text, 1.2)
+		window.DrawScaledText(text, windowW-3*size-w, size-2, 1.2, draw.Red)
+	}
+	if timeNow().Before(c.messageUntil) {
+		window.DrawScaledText(c.message, 10, windowH-30, 1.2, draw.LightGray)
+	}
+}
+
+// videoFrameDue reports whether the video has fewer frames than the recorded
+// time needs.
+func (c *screenCapture) videoFrameDue() bool {
+	return time.Duration(c.recording.Frames())*recordInterval <= c.recorded
+}
+
+// stopRecording writes the video in the background, encoding it takes a
+// moment.
+func (c *screenCapture) stopRecording() {
+	video := c.recording
+	c.recording = nil
+	c.showMessage("Saving video...")
+	name := captureName(".gif")
+	go func() {
+		var buf bytes.Buffer
+		if err := video.Encode(&buf); err != nil {
+			c.saved <- "The video could not be saved: " + err.Error()
+			return
+		}
+		c.saved <- c.saveMessage(name, saveFile(captureFolder, name, buf.Bytes()))
+	}()
+}
+
+func (c *screenCapture) save(name string, data []byte) {
+	c.showMessage(c.saveMessage(name, saveFile(captureFolder, name, data)))
+}
+
+func (c *screenCapture) saveMessage(name string, err error) string {
+	if err != nil {
+		return name + " could not be saved: " + err.Error()
+	}
+	if dir := folderPath(captureFolder); dir != "" {
+		return "Saved " + filepath.Join(dir, name)
+	}
+	return "Downloaded " + name
+}
+
+func (c *screenCapture) showMessage(msg string) {
+	if c.saved == nil {
+		c.saved = make(chan string, 1)
+	}
+	c.message = msg
+	c.messageUntil = timeNow().Add(captureMessageTime)
+}
+
+func captureName(ext string) string {
+	return windowTitle + " " + time.Now().Format("2006-01-02 15-04-05") + ext
+}
+
+// mirror draws to the window and to the offscreen window. Text sizes come from
+// the window so that the layout is the one on the screen.
+type mirror struct {
+	draw.Window
+	offscreen *offscreen.Window
+}
+
+func (m mirror) DrawPoint(x, y int, color draw.Color) {
+	m.Window.DrawPoint(x, y, color)
+	m.offscreen.DrawPoint(x, y, color)
+}
+
+func (m mirror) DrawLine(fromX, fromY, toX, toY int, color draw.Color) {
+	m.Window.DrawLine(fromX, fromY, toX, toY, color)
+	m.offscreen.DrawLine(fromX, fromY, toX, toY, color)
+}
+
+func (m mirror) DrawRect(x, y, width, height int, color draw.Color) {
+	m.Window.DrawRect(x, y, width, height, color)
+	m.offscreen.DrawRect(x, y, width, height, color)
+}
+
+func (m mirror) FillRect(x, y, width, height int, color draw.Color) {
+	m.Window.FillRect(x, y, width, height, color)
+	m.offscreen.FillRect(x, y, width, height, color)
+}
+
+func (m mirror) DrawEllipse(x, y, width, height int, color draw.Color) {
+	m.Window.DrawEllipse(x, y, width, height, color)
+	m.offscreen.DrawEllipse(x, y, width, height, color)
+}
+
+func (m mirror) FillEllipse(x, y, width, height int, color draw.Color) {
+	m.Window.FillEllipse(x, y, width, height, color)
+	m.offscreen.FillEllipse(x, y, width, height, color)
+}
+
+func (m mirror) DrawImageFile(path string, x, y int) error {
+	m.offscreen.DrawImageFile(path, x, y)
+	return m.Window.DrawImageFile(path, x, y)
+}
+
+func (m mirror) DrawImageFileTo(path string, x, y, width, height, degrees int) error {
+	m.offscreen.DrawImageFileTo(path, x, y, width, height, degrees)
+	return m.Window.DrawImageFileTo(path, x, y, width, height, degrees)
+}
+
+func (m mirror) DrawImageFileRotated(path string, x, y, degrees int) error {
+	m.offscreen.DrawImageFileRotated(path, x, y, degrees)
+	return m.Window.DrawImageFileRotated(path, x, y, degrees)
+}
+
+func (m mirror) DrawImageFilePart(
+	path string,
+	sourceX, sourceY, sourceWidth, sourceHeight int,
+	destX, destY, destWidth, destHeight int,
+	rotationCWDeg int,
+) error {
+	m.offscreen.DrawImageFilePart(path,
+		sourceX, sourceY, sourceWidth, sourceHeight,
+		destX, destY, destWidth, destHeight,
+		rotationCWDeg,
+	)
+	return m.Window.DrawImageFilePart(path,
+		sourceX, sourceY, sourceWidth, sourceHeight,
+		destX, destY, destWidth, destHeight,
+		rotationCWDeg,
+	)
+}
+
+func (m mirror) DrawText(text string, x, y int, color draw.Color) {
+	m.Window.DrawText(text, x, y, color)
+	m.offscreen.DrawText(text, x, y, color)
+}
+
+func (m mirror) DrawScaledText(text string, x, y int, scale float32, color draw.Color) {
+	m.Window.DrawScaledText(text, x, y, scale, color)
+	m.offscreen.DrawScaledText(text, x, y, scale, color)
+}
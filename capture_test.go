@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRecordingGoesByTime records one second at different refresh rates and
+// expects the video to have 20 frames per second for all of them.
+func TestRecordingGoesByTime(t *testing.T) {
+	for _, hz := range []int{30, 60, 144} {
+		window := newGoldenWindow(t)
+		var c screenCapture
+		for frame := 0; frame < hz; frame++ {
+			window.Frame()
+			if frame == 0 {
+				window.Press(recordKey)
+			}
+			c.finish(c.window(window))
+			goldenTime = goldenTime.Add(time.Second / time.Duration(hz))
+		}
+		if c.recording == nil {
+			t.Fatalf("%d Hz: the recording did not start", hz)
+		}
+		if n := c.recording.Frames(); n != 20 {
+			t.Errorf("%d Hz: a second of video has %d frames instead of 20", hz, n)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/gonutz/ld41/game"
+	"github.com/gonutz/prototype/draw"
+)
+
+const choiceScale = 2
+
+// choiceRects are the signs with the choices in the MultipleChoice mode,
+// stacked above the player's problem.
+func choiceRects(window draw.Window, p *game.Player) []rectangle {
+	w, h := 0, 0
+	for _, c := range p.Choices {
+		textW, textH := window.GetScaledTextSize(c.String(), choiceScale)
+		if textW > w {
+			w = textW
+		}
+		h = textH
+	}
+	w += 30
+	h += 6
+	x := p.X + (game.PlayerW-w)/2
+	if x < 5 {
+		x = 5
+	}
+	if x+w > windowW-5 {
+		x = windowW - 5 - w
+	}
+	_, questionH := window.GetScaledTextSize("0", 2)
+	bottom := p.Y - 2*questionH - 10
+	rects := make([]rectangle, len(p.Choices))
+	for i := range rects {
+		rects[i] = rectangle{x: x, y: bottom - (len(rects)-i)*(h+4), w: w, h: h}
+	}
+	return rects
+}
+
+func drawChoices(window draw.Window, p *game.Player) {
+	for i, r := range choiceRects(window, p) {
+		color := draw.DarkGray
+		if i == p.Choice {
+			color = draw.DarkRed
+		}
+		window.FillRect(r.x, r.y, r.w, r.h, color)
+		window.DrawRect(r.x, r.y, r.w, r.h, draw.White)
+		text := p.Choices[i].String()
+		w, h := window.GetScaledTextSize(text, choiceScale)
+		window.DrawScaledText(text, r.x+(r.w-w)/2, r.y+(r.h-h)/2, choiceScale, draw.White)
+	}
+}
@@ -0,0 +1,353 @@
+// simulate plays many games without a window to see how the zombie balance
+// works out. A bot answers the problems after a random delay around the given
+// latency and gets a share of them wrong. For each difficulty, the tool prints
+// how the final scores and the survival times are distributed. Try different
+// spawn delays, spawn reductions, zombie speeds and power-up drop chances to
+// tune the game, the defaults are what the game uses.
+//
+// Examples:
+//
+//	simulate -n 2000
+//	simulate -difficulty easy -latency 2.5s -errors 0.2
+//	simulate -spawn-min 1.2s -reduction 0.98 -speed 3
+//	simulate -drops 8,8,6,10
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gonutz/ld41/game"
+	"github.com/gonutz/ld41/mathgen"
+)
+
+func main() {
+	// the float32 is widened through its text to show 0.97, not 0.9700000286
+	defaultReduction, _ := strconv.ParseFloat(fmt.Sprint(game.DefaultBalance.SpawnReduction), 64)
+	var (
+		count         = flag.Int("n", 1000, "number of games per difficulty")
+		level         = flag.String("difficulty", "all", "easy, normal, hard, numerals or all")
+		mode          = flag.String("mode", "classic", "how the bot answers: classic, zombies or choice")
+		latency       = flag.Duration("latency", time.Second, "average time the bot takes to answer a shot problem")
+		reloadLatency = flag.Duration("reload-latency", 2*time.Second, "average time the bot takes to answer a reload problem")
+		typing        = flag.Duration("typing", 150*time.Millisecond, "time the bot takes to type each character of a reload answer")
+		jitter        = flag.Float64("jitter", 0.3, "standard deviation of the answer times, relative to the average")
+		errorRate     = flag.Float64("errors", 0.1, "share of wrong answers, from 0 to 1")
+		maxTime       = flag.Duration("max", 30*time.Minute, "games that last longer are stopped")
+		seed          = flag.Int64("seed", 1, "seed of the first game, the others count up from it")
+		spawnMin      = flag.Duration("spawn-min", game.DefaultBalance.SpawnMin, "smallest time between zombies at the start")
+		spawnMax      = flag.Duration("spawn-max", game.DefaultBalance.SpawnMax, "largest time between zombies at the start")
+		reduction     = flag.Float64("reduction", defaultReduction, "factor for the time between zombies after kills")
+		speed         = flag.Int("speed", game.DefaultBalance.ZombieSpeed, "distance that zombies walk in each step")
+		drops         = flag.String("drops", formatDrops(game.DefaultBalance.PowerUpDrops), "chances in percent that a killed zombie drops piercing bullets, shotgun, slow motion and free answer")
+	)
+	flag.Parse()
+	b := bot{
+		latency:       *latency,
+		reloadLatency: *reloadLatency,
+		typing:        *typing,
+		jitter:        *jitter,
+		errorRate:     *errorRate,
+	}
+	powerUpDrops, err := parseDrops(*drops)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+	balance := game.Balance{
+		SpawnMin:       *spawnMin,
+		SpawnMax:       *spawnMax,
+		SpawnReduction: float32(*reduction),
+		ZombieSpeed:    *speed,
+		PowerUpDrops:   powerUpDrops,
+	}
+	if err := run(*count, *level, *mode, b, *maxTime, *seed, balance); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(count int, level, modeName string, b bot, maxTime time.Duration, seed int64, balance game.Balance) error {
+	var difficulties []mathgen.Difficulty
+	for _, d := range mathgen.Difficulties {
+		if level == "all" || strings.EqualFold(d.String(), level) {
+			difficulties = append(difficulties, d)
+		}
+	}
+	if len(difficulties) == 0 {
+		return fmt.Errorf("unknown difficulty %q, use easy, normal, hard, numerals or all", level)
+	}
+	modes := map[string]game.Mode{
+		"classic": game.Classic,
+		"zombies": game.ZombieProblems,
+		"choice":  game.MultipleChoice,
+	}
+	mode, ok := modes[modeName]
+	if !ok {
+		return fmt.Errorf("unknown mode %q, use classic, zombies or choice", modeName)
+	}
+	if count < 1 {
+		return errors.New("-n must be at least 1")
+	}
+	if b.errorRate < 0 || b.errorRate > 1 {
+		return errors.New("-errors must be between 0 and 1")
+	}
+	if balance.SpawnMin <= 0 || balance.SpawnMax < balance.SpawnMin {
+		return errors.New("-spawn-min must be greater than 0 and at most -spawn-max")
+	}
+	if balance.SpawnReduction <= 0 || balance.SpawnReduction > 1 {
+		return errors.New("-reduction must be greater than 0 and at most 1")
+	}
+	if balance.ZombieSpeed < 1 {
+		return errors.New("-speed must be at least 1")
+	}
+
+	fmt.Printf("%d games per difficulty, %s mode, answers after %v (reloads %v), %.0f%% wrong\n",
+		count, mode, b.latency, b.reloadLatency, 100*b.errorRate)
+	fmt.Printf("spawn delay %v to %v, reduction %g, zombie speed %d, drops %s\n\n",
+		balance.SpawnMin, balance.SpawnMax, balance.SpawnReduction, balance.ZombieSpeed,
+		formatDrops(balance.PowerUpDrops))
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "difficulty\t\tmin\t10%\t25%\tmedian\t75%\t90%\tmax\tmean\t")
+	for _, d := range difficulties {
+		results := simulate(count, seed, b, maxTime, game.Settings{
+			Players: 1,
+			Mode:    mode,
+			Choices: 4,
+			Shots:   d.Shots(),
+			Reloads: d.Reloads(),
+			Balance: balance,
+		})
+		scores := make([]int, len(results))
+		seconds := make([]int, len(results))
+		stopped := 0
+		for i, r := range results {
+			scores[i] = r.score
+			seconds[i] = r.seconds
+			if r.survived {
+				stopped++
+			}
+		}
+		fmt.Fprintln(table, d.String()+"\tscore\t"+distribution(scores, strconv.Itoa))
+		fmt.Fprintln(table, "\tsurvival\t"+distribution(seconds, func(s int) string {
+			return fmt.Sprintf("%d:%02d", s/60, s%60)
+		}))
+		if stopped > 0 {
+			fmt.Fprintf(table, "\t%d games were stopped after %v\t\t\t\t\t\t\t\t\n", stopped, maxTime)
+		}
+	}
+	return table.Flush()
+}
+
+// formatDrops writes the drop chances as a comma separated list.
+func formatDrops(drops [game.PowerUpKindCount]int) string {
+	s := make([]string, len(drops))
+	for i, percent := range drops {
+		s[i] = strconv.Itoa(percent)
+	}
+	return strings.Join(s, ",")
+}
+
+// parseDrops reads the drop chances written by formatDrops.
+func parseDrops(s string) ([game.PowerUpKindCount]int, error) {
+	var drops [game.PowerUpKindCount]int
+	parts := strings.Split(s, ",")
+	if len(parts) != len(drops) {
+		return drops, fmt.Errorf("-drops needs %d chances, one for each power-up", len(drops))
+	}
+	sum := 0
+	for i, part := range parts {
+		percent, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || percent < 0 {
+			return drops, fmt.Errorf("-drops has the invalid chance %q", part)
+		}
+		drops[i] = percent
+		sum += percent
+	}
+	if sum > 100 {
+		return drops, errors.New("-drops must not add up to more than 100")
+	}
+	return drops, nil
+}
+
+type result struct {
+	score    int
+	seconds  int  // survival time
+	survived bool // the game was stopped at the time limit
+}
+
+// simulate plays the games with seeds seed, seed+1 and so on, on all CPUs.
+func simulate(count int, seed int64, b bot, maxTime time.Duration, settings game.Settings) []result {
+	results := make([]result, count)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				s := settings
+				s.Seed = seed + int64(i)
+				results[i] = play(s, b, maxTime)
+			}
+		}()
+	}
+	for i := range results {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// play runs one game until the player is eaten or the time is up.
+func play(settings game.Settings, b bot, maxTime time.Duration) result {
+	w := game.New(settings)
+	r := rand.New(rand.NewSource(settings.Seed))
+	wait := b.answerTime(w, r)
+	for !w.AllDying() && w.Elapsed < game.Ticks(maxTime) {
+		var in game.PlayerInput
+		if b.turn(w, &in) {
+			// the bot cannot shoot the other way before turning around
+		} else if wait--; wait <= 0 && w.Players[0].ShootBan == 0 {
+			b.answer(w, &in, r)
+			wait = b.answerTime(w, r)
+		}
+		w.Step(game.Input{Players: []game.PlayerInput{in}})
+	}
+	return result{
+		score:    w.Score(),
+		seconds:  w.Elapsed / game.TicksPerSecond,
+		survived: !w.AllDying(),
+	}
+}
+
+// bot plays the game like a child who knows most answers but needs time to
+// come up with them.
+type bot struct {
+	latency       time.Duration // average answer time for shot problems
+	reloadLatency time.Duration // average answer time for reload problems
+	typing        time.Duration // added for each character of a reload answer
+	jitter        float64       // relative standard deviation of answer times
+	errorRate     float64       // share of wrong answers
+}
+
+// answerTime returns the number of steps until the bot answers the current
+// problem.
+func (b bot) answerTime(w *game.World, r *rand.Rand) int {
+	mean := b.latency
+	if p := &w.Players[0]; p.Ammo == 0 {
+		mean = b.reloadLatency
+		if w.Settings.Mode != game.MultipleChoice {
+			mean += time.Duration(len(p.ReloadAssignment.Answer.String())) * b.typing
+		}
+	}
+	d := time.Duration(float64(mean) * (1 + b.jitter*r.NormFloat64()))
+	if min := 100 * time.Millisecond; d < min {
+		d = min
+	}
+	return game.Ticks(d)
+}
+
+// turn makes the player face the zombie that comes closest to them and
+// reports whether the player has to turn around first.
+func (b bot) turn(w *game.World, in *game.PlayerInput) bool {
+	p := &w.Players[0]
+	closest := -1
+	for i, z := range w.Zombies {
+		if closest == -1 || dist(z, p) < dist(w.Zombies[closest], p) {
+			closest = i
+		}
+	}
+	if closest == -1 {
+		return false
+	}
+	left := w.Zombies[closest].X < p.X
+	if left != p.FacingLeft {
+		in.Left, in.Right = left, !left
+		return true
+	}
+	return false
+}
+
+func dist(z game.Zombie, p *game.Player) int {
+	d := z.X - p.X
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// answer solves the player's problem, or gets it wrong with the bot's error
+// rate.
+func (b bot) answer(w *game.World, in *game.PlayerInput, r *rand.Rand) {
+	p := &w.Players[0]
+	wrong := r.Float64() < b.errorRate
+	if p.Tokens > 0 && !wrong {
+		in.Token = true
+		return
+	}
+	if w.Settings.Mode == game.MultipleChoice {
+		pick := -1
+		for i, c := range p.Choices {
+			if c.Equal(p.Problem().Answer) != wrong && (pick == -1 || r.Intn(2) == 0) {
+				pick = i
+			}
+		}
+		in.ClickedChoice = pick + 1
+		return
+	}
+	if p.Ammo == 0 {
+		in.Chars = []rune(p.ReloadAssignment.Answer.String())
+		if wrong {
+			// a different last digit makes a different number
+			last := &in.Chars[len(in.Chars)-1]
+			*last = '0' + (*last-'0'+1+rune(r.Intn(9)))%10
+			in.PickChoice = true
+		}
+		return
+	}
+	answer := p.Assignment.Answer.Int()
+	if w.Settings.Mode == game.ZombieProblems {
+		target := -1
+		for i, z := range w.Zombies {
+			if !z.Targeted && (target == -1 || dist(z, p) < dist(w.Zombies[target], p)) {
+				target = i
+			}
+		}
+		if target == -1 {
+			return
+		}
+		answer = w.Zombies[target].Assignment.Answer.Int()
+	}
+	if wrong {
+		answer = (answer + 1 + r.Intn(9)) % 10
+	}
+	in.Digits[answer] = true
+}
+
+// distribution formats the percentiles and the mean of the values.
+func distribution(values []int, format func(int) string) string {
+	sort.Ints(values)
+	at := func(percent int) string {
+		return format(values[(len(values)-1)*percent/100])
+	}
+	sum := 0
+	for _, v := range values {
+		sum += v
+	}
+	return strings.Join([]string{
+		at(0), at(10), at(25), at(50), at(75), at(90), at(100),
+		format(sum / len(values)),
+	}, "\t") + "\t"
+}
@@ -0,0 +1,321 @@
+// worksheet prints math problems on paper so kids can practice the same facts
+// that the game asks, without a computer. It writes a sheet with the problems
+// and a separate answer key, either as plain text, HTML or SVG. Using the same
+// seed creates the same sheet again.
+//
+// Examples:
+//
+//	worksheet -n 40 -o sheet
+//	worksheet -format html -ops * -table 7 -seed 12 -o seven
+//	worksheet -difficulty hard -preset reloads -format svg -stats
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gonutz/ld41/mathgen"
+)
+
+const columns = 4
+
+func main() {
+	var (
+		format = flag.String("format", "txt", "output format: txt, html or svg")
+		count  = flag.Int("n", 40, "number of problems")
+		seed   = flag.Int64("seed", 0, "random seed, 0 picks one and prints it on the sheet")
+		output = flag.String("o", "worksheet", "output file name without extension, the answer key gets the suffix _answers")
+		level  = flag.String("difficulty", "normal", "difficulty like in the game: easy, normal, hard or numerals")
+		preset = flag.String("preset", "shots", "problems like in the game: shots (single digit answers) or reloads")
+		ops    = flag.String("ops", "", "operations to use, e.g. +- or */ or ^ sqrt mod or bin hex roman, overrides the preset")
+		max    = flag.Int("max", 0, "largest answer, overrides the preset")
+		table  = flag.Int("table", 0, "times table to use for * and /, like in practice mode")
+		by     = flag.String("by", "answer", "what is equally likely: answer or problem")
+		stats  = flag.Bool("stats", false, "print how often each answer and operation occurs")
+	)
+	flag.Parse()
+	if err := run(*format, *count, *seed, *output, *level, *preset, *ops, *max, *table, *by, *stats); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(format string, count int, seed int64, output, level, preset, ops string, max, table int, by string, stats bool) error {
+	difficulty := mathgen.Difficulty(-1)
+	for _, d := range mathgen.Difficulties {
+		if strings.EqualFold(d.String(), level) {
+			difficulty = d
+		}
+	}
+	if difficulty == -1 {
+		return fmt.Errorf("unknown difficulty %q, use easy, normal, hard or numerals", level)
+	}
+	var g mathgen.Generator
+	switch preset {
+	case "shots":
+		g = difficulty.Shots()
+	case "reloads":
+		g = difficulty.Reloads()
+	default:
+		return fmt.Errorf("unknown preset %q, use shots or reloads", preset)
+	}
+	if ops != "" {
+		var err error
+		if g.Ops, err = parseOps(ops); err != nil {
+			return err
+		}
+	}
+	if max < 0 || table < 0 {
+		return errors.New("-max and -table must not be negative")
+	}
+	if max != 0 {
+		g.Max = max
+	}
+	g.Factor = table
+	switch by {
+	case "answer":
+		g.Weighting = mathgen.ByAnswer
+	case "problem":
+		g.Weighting = mathgen.ByProblem
+	default:
+		return fmt.Errorf("unknown weighting %q for -by, use answer or problem", by)
+	}
+	if g.Max == 0 && g.Factor == 0 {
+		return errors.New("-max or -table must be greater than 0")
+	}
+	if count < 1 {
+		return errors.New("-n must be at least 1")
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano() % 1000000
+	}
+
+	problems := generate(g, count, seed)
+	if stats {
+		printStats(problems)
+	}
+
+	var write func(title string, problems []mathgen.Assignment, answers bool) string
+	switch format {
+	case "txt":
+		write = writeText
+	case "html":
+		write = writeHTML
+	case "svg":
+		write = writeSVG
+	default:
+		return fmt.Errorf("unknown format %q, use txt, html or svg", format)
+	}
+	title := fmt.Sprintf("Math Practice %s (seed %d)", describe(g), seed)
+	if ops == "" && max == 0 && table == 0 {
+		title = fmt.Sprintf("Math Practice %s %s (seed %d)", difficulty, preset, seed)
+	}
+	sheet := output + "." + format
+	key := output + "_answers." + format
+	if err := os.WriteFile(sheet, []byte(write(title, problems, false)), 0666); err != nil {
+		return err
+	}
+	if err := os.WriteFile(key, []byte(write(title+" - Answers", problems, true)), 0666); err != nil {
+		return err
+	}
+	fmt.Println("wrote", sheet, "and", key)
+	return nil
+}
+
+// parseOps reads operation symbols like "+-*/" or "^ sqrt mod".
+func parseOps(s string) ([]mathgen.Op, error) {
+	var ops []mathgen.Op
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		found := false
+		for op := mathgen.Op(0); op < mathgen.Custom; op++ {
+			if strings.HasPrefix(s, op.String()) {
+				ops = append(ops, op)
+				s = s[len(op.String()):]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown operation at %q in -ops, use + - * / ^ sqrt mod frac dec bin hex place roman", s)
+		}
+	}
+	return ops, nil
+}
+
+// generate creates the problems the same way that the game does, the same
+// problem never comes twice in a row.
+func generate(g mathgen.Generator, count int, seed int64) []mathgen.Assignment {
+	r := rand.New(rand.NewSource(seed))
+	problems := make([]mathgen.Assignment, count)
+	for i := range problems {
+		problems[i] = g.Generate(r.Int)
+		for try := 0; i > 0 && try < 100 && problems[i] == problems[i-1]; try++ {
+			problems[i] = g.Generate(r.Int)
+		}
+	}
+	return problems
+}
+
+func describe(g mathgen.Generator) string {
+	var ops []string
+	seen := make(map[mathgen.Op]bool)
+	for _, op := range g.Ops {
+		if !seen[op] {
+			seen[op] = true
+			ops = append(ops, op.String())
+		}
+	}
+	text := strings.Join(ops, " ")
+	if g.Factor != 0 {
+		return fmt.Sprintf("%s, times table of %d", text, g.Factor)
+	}
+	return fmt.Sprintf("%s, answers up to %d", text, g.Max)
+}
+
+func blank(a mathgen.Assignment, answers bool) string {
+	if answers {
+		return a.Answer.String()
+	}
+	return "____"
+}
+
+// equation puts the blank after the question or, for questions like
+// 1/2 + 1/4 = ?/4, in place of the question mark.
+func equation(question string, a mathgen.Assignment, answers bool) string {
+	if strings.Contains(question, "?") {
+		return strings.Replace(question, "?", blank(a, answers), 1)
+	}
+	return question + " = " + blank(a, answers)
+}
+
+// pretty formats the question for HTML and SVG which, unlike the game's font,
+// can show a root sign and exponents.
+func pretty(a mathgen.Assignment, superStart, superEnd string) string {
+	q := html.EscapeString(a.Question)
+	switch a.Op {
+	case mathgen.SquareRoot:
+		return strings.Replace(q, "sqrt ", "\u221a", 1)
+	case mathgen.Power:
+		base, exponent, _ := strings.Cut(q, "^")
+		return base + superStart + exponent + superEnd
+	}
+	return q
+}
+
+func writeText(title string, problems []mathgen.Assignment, answers bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\nName: ____________________  Date: __________\n\n", title)
+	rows := (len(problems) + columns - 1) / columns
+	for row := 0; row < rows; row++ {
+		line := ""
+		for col := 0; col < columns; col++ {
+			i := col*rows + row
+			if i < len(problems) {
+				cell := fmt.Sprintf("%3d)  %s", i+1, equation(problems[i].Question, problems[i], answers))
+				line += fmt.Sprintf("%-24s", cell)
+			}
+		}
+		b.WriteString(strings.TrimRight(line, " ") + "\n\n")
+	}
+	return b.String()
+}
+
+func writeHTML(title string, problems []mathgen.Assignment, answers bool) string {
+	var b strings.Builder
+	title = html.EscapeString(title)
+	fmt.Fprintf(&b, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { font-family: sans-serif; margin: 2cm; }
+table { width: 100%%; border-collapse: collapse; }
+td { font-size: 16pt; padding: 0.5em 0; width: %d%%; }
+.number { color: gray; font-size: 10pt; }
+</style>
+</head>
+<body>
+<h1>%s</h1>
+<p>Name: ____________________ Date: __________</p>
+<table>
+`, title, 100/columns, title)
+	rows := (len(problems) + columns - 1) / columns
+	for row := 0; row < rows; row++ {
+		b.WriteString("<tr>")
+		for col := 0; col < columns; col++ {
+			i := col*rows + row
+			if i < len(problems) {
+				fmt.Fprintf(&b, `<td><span class="number">%d)</span> %s</td>`,
+					i+1, equation(pretty(problems[i], "<sup>", "</sup>"), problems[i], answers))
+			} else {
+				b.WriteString("<td></td>")
+			}
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</table>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// writeSVG lays the problems out on pages the size of A4 paper, in mm.
+func writeSVG(title string, problems []mathgen.Assignment, answers bool) string {
+	const (
+		pageW, margin = 210, 20
+		top           = 45 // below the title and name lines
+		lineH         = 10
+	)
+	rows := (len(problems) + columns - 1) / columns
+	pageH := top + rows*lineH + margin
+	if pageH < 297 {
+		pageH = 297
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%dmm" height="%dmm" viewBox="0 0 %d %d" font-family="sans-serif">
+<rect width="100%%" height="100%%" fill="white"/>
+<text x="%d" y="%d" font-size="8">%s</text>
+<text x="%d" y="%d" font-size="5">Name: ____________________ Date: __________</text>
+`, pageW, pageH, pageW, pageH, margin, margin+5, html.EscapeString(title), margin, margin+15)
+	colW := (pageW - 2*margin) / columns
+	for i, a := range problems {
+		x := margin + (i/rows)*colW
+		y := top + (i%rows)*lineH
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="5"><tspan fill="gray" font-size="3">%d)</tspan> %s</text>
+`, x, y, i+1, equation(pretty(a, `<tspan baseline-shift="super" font-size="3">`, "</tspan>"), a, answers))
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// printStats shows a bar for each answer and operation, which makes it easy to
+// see whether the generator favors some problems.
+func printStats(problems []mathgen.Assignment) {
+	answers := make(map[mathgen.Number]int)
+	ops := make(map[mathgen.Op]int)
+	for _, a := range problems {
+		answers[a.Answer]++
+		ops[a.Op]++
+	}
+	var keys []mathgen.Number
+	for answer := range answers {
+		keys = append(keys, answer)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Less(keys[j]) })
+	fmt.Println("answers:")
+	for _, answer := range keys {
+		fmt.Printf("%5s %4d %s\n", answer, answers[answer], strings.Repeat("#", answers[answer]))
+	}
+	fmt.Println("operations:")
+	for op := mathgen.Op(0); op < mathgen.OpCount; op++ {
+		if ops[op] > 0 {
+			fmt.Printf("%5s %4d %s\n", op, ops[op], strings.Repeat("#", ops[op]))
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"github.com/gonutz/ld41/game"
+	"github.com/gonutz/prototype/draw"
+)
+
+// controls are the keys that a player uses. In single player mode, one player
+// can use all keys, in co-op mode the keyboard is split in half.
+type controls struct {
+	digits      [10][]draw.Key
+	left, right []draw.Key
+	token       []draw.Key
+	// symbols allows typing decimal points and fraction slashes. These are
+	// read from the typed characters so only one player can use them.
+	symbols bool
+	// these move between and pick the signs in the MultipleChoice mode
+	prevChoice, nextChoice, pickChoice []draw.Key
+}
+
+var (
+	allKeys = controls{
+		digits: [10][]draw.Key{
+			{draw.Key0, draw.KeyNum0},
+			{draw.Key1, draw.KeyNum1},
+			{draw.Key2, draw.KeyNum2},
+			{draw.Key3, draw.KeyNum3},
+			{draw.Key4, draw.KeyNum4},
+			{draw.Key5, draw.KeyNum5},
+			{draw.Key6, draw.KeyNum6},
+			{draw.Key7, draw.KeyNum7},
+			{draw.Key8, draw.KeyNum8},
+			{draw.Key9, draw.KeyNum9},
+		},
+		left:       []draw.Key{draw.KeyLeft, draw.KeyA},
+		right:      []draw.Key{draw.KeyRight, draw.KeyD},
+		token:      []draw.Key{draw.KeySpace},
+		symbols:    true,
+		prevChoice: []draw.Key{draw.KeyUp, draw.KeyW},
+		nextChoice: []draw.Key{draw.KeyDown, draw.KeyS},
+		pickChoice: []draw.Key{draw.KeyEnter, draw.KeyNumEnter},
+	}
+	leftKeys = controls{
+		digits: [10][]draw.Key{
+			{draw.Key0},
+			{draw.Key1},
+			{draw.Key2},
+			{draw.Key3},
+			{draw.Key4},
+			{draw.Key5},
+			{draw.Key6},
+			{draw.Key7},
+			{draw.Key8},
+			{draw.Key9},
+		},
+		left:       []draw.Key{draw.KeyA},
+		right:      []draw.Key{draw.KeyD},
+		token:      []draw.Key{draw.KeySpace},
+		prevChoice: []draw.Key{draw.KeyW},
+		nextChoice: []draw.Key{draw.KeyS},
+		pickChoice: []draw.Key{draw.KeyE},
+	}
+	numpadKeys = controls{
+		digits: [10][]draw.Key{
+			{draw.KeyNum0},
+			{draw.KeyNum1},
+			{draw.KeyNum2},
+			{draw.KeyNum3},
+			{draw.KeyNum4},
+			{draw.KeyNum5},
+			{draw.KeyNum6},
+			{draw.KeyNum7},
+			{draw.KeyNum8},
+			{draw.KeyNum9},
+		},
+		left:       []draw.Key{draw.KeyLeft},
+		right:      []draw.Key{draw.KeyRight},
+		token:      []draw.Key{draw.KeyNumEnter},
+		prevChoice: []draw.Key{draw.KeyUp},
+		nextChoice: []draw.Key{draw.KeyDown},
+		pickChoice: []draw.Key{draw.KeyNumAdd},
+	}
+)
+
+// pressedDigit returns the digit that was typed in the last frame, if any.
+func (c *controls) pressedDigit(window draw.Window) (digit int, ok bool) {
+	for n, keys := range c.digits {
+		if anyKeyPressed(window, keys) {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// pressedAnswerKey returns the digit or symbol that was typed in the last frame,
+// if any. A decimal comma is returned as a decimal point.
+func (c *controls) pressedAnswerKey(window draw.Window) (char rune, ok bool) {
+	if digit, ok := c.pressedDigit(window); ok {
+		return rune('0' + digit), true
+	}
+	if c.symbols {
+		for _, r := range window.Characters() {
+			switch r {
+			case '.', ',':
+				return '.', true
+			case '/':
+				return '/', true
+			}
+		}
+	}
+	return 0, false
+}
+
+func anyKeyPressed(window draw.Window, keys []draw.Key) bool {
+	for _, key := range keys {
+		if window.WasKeyPressed(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyKeyDown(window draw.Window, keys []draw.Key) bool {
+	for _, key := range keys {
+		if window.IsKeyDown(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// read adds what was pressed in the last frame to the input. Keys that are held
+// down replace the old state, presses are kept until the input is cleared.
+func (c *controls) read(window draw.Window, in *game.PlayerInput) {
+	in.Left = anyKeyDown(window, c.left)
+	in.Right = anyKeyDown(window, c.right)
+	for n, keys := range c.digits {
+		if anyKeyPressed(window, keys) {
+			in.Digits[n] = true
+			in.Chars = append(in.Chars, rune('0'+n))
+		}
+	}
+	if c.symbols {
+		for _, r := range window.Characters() {
+			switch r {
+			case '.', ',':
+				in.Chars = append(in.Chars, '.')
+			case '/':
+				in.Chars = append(in.Chars, '/')
+			}
+		}
+	}
+	in.Token = in.Token || anyKeyPressed(window, c.token)
+	in.PrevChoice = in.PrevChoice || anyKeyPressed(window, c.prevChoice)
+	in.NextChoice = in.NextChoice || anyKeyPressed(window, c.nextChoice)
+	in.PickChoice = in.PickChoice || anyKeyPressed(window, c.pickChoice)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The daily challenge seeds zombies and assignments with the current date so
+// everybody who plays on the same day gets the same run. All days share one
+// file in which each high score line is prefixed with its date, this way the
+// history of past days can be shown as well.
+const (
+	dailyFile        = "brainless_jogging_daily"
+	dailyTablePrefix = "_daily_"
+	dailyDateFormat  = "2006-01-02"
+)
+
+func today() string {
+	return time.Now().Format(dailyDateFormat)
+}
+
+// dailySeed turns the date into a number like 20180421.
+func dailySeed(date string) int64 {
+	t, err := time.Parse(dailyDateFormat, date)
+	if err != nil {
+		return 0
+	}
+	return int64(t.Year()*10000 + int(t.Month())*100 + t.Day())
+}
+
+func dailyTable(date string) string {
+	return dailyTablePrefix + date
+}
+
+func dailyTableDate(table string) (date string, ok bool) {
+	if strings.HasPrefix(table, dailyTablePrefix) {
+		return strings.TrimPrefix(table, dailyTablePrefix), true
+	}
+	return "", false
+}
+
+// loadDailyScores returns the high scores for each date.
+func loadDailyScores() map[string][]highscore {
+	scores := make(map[string][]highscore)
+	for _, line := range strings.Split(loadText(dailyFile), "\n") {
+		cols := strings.SplitN(line, " ", 2)
+		if len(cols) == 2 {
+			scores[cols[0]] = append(scores[cols[0]], parseHighscores(cols[1])...)
+		}
+	}
+	return scores
+}
+
+func saveDailyScores(scores map[string][]highscore) {
+	var dates []string
+	for date := range scores {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	text := ""
+	for _, date := range dates {
+		for _, score := range scores[date] {
+			if score.score > 0 {
+				text += fmt.Sprintf("%s %d %s\n", date, score.score, score.name)
+			}
+		}
+	}
+	saveText(dailyFile, text)
+}
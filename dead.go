@@ -25,6 +25,14 @@ type deadState struct {
 	cursorBlink    int
 	cursorVisible  bool
 	score          int
+	players        []playerResult
+	table          string // the high score table that is shown
+	tableTitle     string
+	sprint         time.Duration
+}
+
+type playerResult struct {
+	score, kills int
 }
 
 func (s *deadState) enter(oldState state) {
@@ -32,15 +40,35 @@ func (s *deadState) enter(oldState state) {
 	s.restartVisible = true
 	s.blink = 0
 	s.editing = -1
-	s.highscores = loadHighScores()
+	s.table = mainTable
+	s.tableTitle = ""
+	s.sprint = 0
+	if oldState == playing && playing.settings.daily != "" {
+		s.table = dailyTable(playing.settings.daily)
+		s.tableTitle = "Daily Challenge " + playing.settings.daily
+	}
+	if oldState == playing && playing.settings.sprint != 0 {
+		s.sprint = playing.settings.sprint
+		s.table = sprintTable(s.sprint)
+		s.tableTitle = fmt.Sprintf("Sprint %d s, zombies killed", int(s.sprint/time.Second))
+	}
+	s.highscores = loadHighScores(s.table)
 	if len(s.highscores) < maxHighScores {
 		s.highscores = append(s.highscores, make([]highscore, maxHighScores-len(s.highscores))...)
 	}
 	s.caption = "High Scores"
 	if oldState == playing {
 		s.caption = "You were eaten alive!"
-		score := playing.score
+		score := playing.world.Score()
+		if s.sprint != 0 {
+			s.caption = "Time's up!"
+			score = playing.world.Kills()
+		}
 		s.score = score
+		s.players = nil
+		for _, p := range playing.world.Players {
+			s.players = append(s.players, playerResult{score: p.Score, kills: p.Kills})
+		}
 		s.highscores = append(s.highscores, highscore{
 			score: score,
 			id:    1,
@@ -49,7 +77,7 @@ func (s *deadState) enter(oldState state) {
 		if len(s.highscores) > maxHighScores {
 			s.highscores = s.highscores[:maxHighScores]
 		}
-		saveHighScores(s.highscores)
+		saveHighScores(s.table, s.highscores)
 		s.editing = -1
 		for i := range s.highscores {
 			if s.highscores[i].id == 1 {
@@ -73,7 +101,7 @@ func (s *deadState) update(window draw.Window) state {
 	if window.WasKeyPressed(draw.KeyEnter) || window.WasKeyPressed(draw.KeyNumEnter) {
 		if s.editing != -1 {
 			s.editing = -1
-			saveHighScores(s.highscores)
+			saveHighScores(s.table, s.highscores)
 			s.restartVisible = false
 			s.blink = 0
 		} else {
@@ -117,7 +145,7 @@ func (s *deadState) update(window draw.Window) state {
 	// highscores
 	const scoreScale = 2
 	lineW, lineH := window.GetScaledTextSize(
-		strings.Repeat("A", maxNameLen+len("1.  25")),
+		strings.Repeat("A", maxNameLen+len("1.  12345")),
 		scoreScale,
 	)
 	scoresY := (windowH - 5*lineH) / 2
@@ -136,6 +164,10 @@ func (s *deadState) update(window draw.Window) state {
 		scoreText := fmt.Sprintf("%d. %s%s%d", i+1, name, space, score.score)
 		window.DrawScaledText(scoreText, (windowW-lineW)/2, scoresY+i*lineH, scoreScale, draw.White)
 	}
+	if s.tableTitle != "" {
+		w, h := window.GetScaledTextSize(s.tableTitle, scoreScale)
+		window.DrawScaledText(s.tableTitle, (windowW-w)/2, scoresY-h-10, scoreScale, draw.Yellow)
+	}
 	// title and instructions
 	const (
 		msg       = "Press ENTER to play"
@@ -149,13 +181,44 @@ func (s *deadState) update(window draw.Window) state {
 	}
 	// score
 	if s.score >= 0 {
-		suffix := "s"
-		if s.score == 1 {
-			suffix = ""
+		kills := 0
+		for _, p := range s.players {
+			kills += p.kills
+		}
+		subject := "You"
+		if len(s.players) > 1 {
+			subject = "Together you"
+		}
+		text := fmt.Sprintf("%s killed %s for %s", subject, zombieCount(kills), pointCount(s.score))
+		if s.sprint != 0 {
+			text = fmt.Sprintf("%s killed %s in %d seconds", subject, zombieCount(kills), int(s.sprint/time.Second))
 		}
-		text := fmt.Sprintf("You killed %d zombie%s", s.score, suffix)
-		w, _ := window.GetScaledTextSize(text, textScale)
+		w, h := window.GetScaledTextSize(text, textScale)
 		window.DrawScaledText(text, (windowW-w)/2, 30, textScale, draw.DarkRed)
+		if len(s.players) > 1 {
+			var parts []string
+			for i, p := range s.players {
+				parts = append(parts, fmt.Sprintf("P%d: %s, %s", i+1, zombieCount(p.kills), pointCount(p.score)))
+			}
+			text := strings.Join(parts, "   ")
+			const scale = 2
+			w, _ := window.GetScaledTextSize(text, scale)
+			window.DrawScaledText(text, (windowW-w)/2, 30+h, scale, draw.DarkRed)
+		}
 	}
 	return nextState
 }
+
+func zombieCount(n int) string {
+	if n == 1 {
+		return "1 zombie"
+	}
+	return fmt.Sprintf("%d zombies", n)
+}
+
+func pointCount(n int) string {
+	if n == 1 {
+		return "1 point"
+	}
+	return fmt.Sprintf("%d points", n)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/gonutz/ld41/game"
+	"github.com/gonutz/prototype/draw"
+)
+
+// soundEffects turns the events into sounds. The window is only at hand while
+// rendering, so the sounds are collected and played once per frame.
+type soundEffects struct {
+	queue []string
+}
+
+func (fx *soundEffects) handle(e game.Event) {
+	switch e := e.(type) {
+	case game.AnswerSubmitted:
+		if !e.Correct {
+			fx.queue = append(fx.queue, "miss shot.wav")
+		}
+	case game.ShotFired, game.PlayerDied:
+		fx.queue = append(fx.queue, "shot.wav")
+	case game.GunReloaded:
+		fx.queue = append(fx.queue, "reload.wav")
+	case game.ZombieKilled:
+		fx.queue = append(fx.queue, fmt.Sprintf("zombie death %d.wav", rand.Intn(zombieDeathSounds)))
+	case game.PlayerGaveUp:
+		fx.queue = append(fx.queue, "uh oh.wav")
+	case game.PowerUpCollected, game.ChoiceMoved:
+		fx.queue = append(fx.queue, "menu beep.wav")
+	}
+}
+
+func (fx *soundEffects) play(window draw.Window) {
+	for _, file := range fx.queue {
+		window.PlaySoundFile(file)
+	}
+	fx.queue = fx.queue[:0]
+}
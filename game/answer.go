@@ -0,0 +1,58 @@
+package game
+
+import (
+	"strings"
+
+	"github.com/gonutz/ld41/mathgen"
+)
+
+// AnswerInput collects the characters of an answer that may have more than one
+// digit, a decimal point or a fraction slash. The answer is taken as soon as
+// the input has its value, a wrong answer is only checked once the player
+// submits it.
+type AnswerInput struct {
+	Text string
+}
+
+// Add appends the character to the input. Once the input has the answer's
+// value, it is cleared and returned as complete and correct. Answers with the
+// same value count as correct, e.g. 6/8 and 0.75 for 3/4.
+func (in *AnswerInput) Add(char rune, answer mathgen.Number) (typed string, complete, correct bool) {
+	in.Text += string(char)
+	if n, err := mathgen.ParseNumber(in.Text); err == nil && n.Equal(answer) {
+		typed = in.Text
+		in.Text = ""
+		return typed, true, true
+	}
+	return "", false, false
+}
+
+// Submit completes the input unless it is empty. It is cleared and the typed
+// text is returned along with whether it was correct.
+func (in *AnswerInput) Submit(answer mathgen.Number) (typed string, complete, correct bool) {
+	if in.Text == "" {
+		return "", false, false
+	}
+	typed = in.Text
+	n, err := mathgen.ParseNumber(typed)
+	correct = err == nil && n.Equal(answer)
+	in.Text = ""
+	return typed, true, correct
+}
+
+func (in *AnswerInput) Clear() {
+	in.Text = ""
+}
+
+// Prompt shows the question together with the input. Questions that ask for
+// part of the answer, like 1/2 + 1/4 = ?/4, get the input in place of the
+// question mark.
+func Prompt(a mathgen.Assignment, input string) string {
+	if strings.Contains(a.Question, "?") {
+		if input == "" {
+			return a.Question
+		}
+		return strings.Replace(a.Question, "?", input, 1)
+	}
+	return a.Question + " = " + input
+}
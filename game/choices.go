@@ -0,0 +1,70 @@
+package game
+
+import (
+	"time"
+
+	"github.com/gonutz/ld41/mathgen"
+)
+
+// updateChoices creates new signs whenever a player's problem changes.
+func (w *World) updateChoices() {
+	if w.Settings.Mode != MultipleChoice {
+		return
+	}
+	for i := range w.Players {
+		p := &w.Players[i]
+		if a := p.Problem(); a != p.choicesFor || p.Choices == nil {
+			p.choicesFor = a
+			p.Choices = mathgen.Choices(a, w.Settings.Choices, p.rand.Int)
+			p.Choice = 0
+		}
+	}
+}
+
+// pickChoice lets the player move between the signs and pick one of them. A
+// sign is picked with the pick key, by typing its number or by clicking it.
+// Picking the right answer shoots or reloads the gun.
+func (w *World) pickChoice(p *Player, in PlayerInput) {
+	n := len(p.Choices)
+	if in.PrevChoice {
+		p.Choice = (p.Choice + n - 1) % n
+		w.Events.Publish(ChoiceMoved{Player: w.playerIndex(p)})
+	}
+	if in.NextChoice {
+		p.Choice = (p.Choice + 1) % n
+		w.Events.Publish(ChoiceMoved{Player: w.playerIndex(p)})
+	}
+	picked := -1
+	if in.PickChoice {
+		picked = p.Choice
+	}
+	// typing a number that is not on a sign is ignored
+	for digit, pressed := range in.Digits {
+		if pressed {
+			for i, c := range p.Choices {
+				if c.Equal(mathgen.Whole(digit)) {
+					picked = i
+				}
+			}
+		}
+	}
+	if 1 <= in.ClickedChoice && in.ClickedChoice <= n {
+		picked = in.ClickedChoice - 1
+	}
+	if picked == -1 {
+		return
+	}
+	p.Choice = picked
+	a := p.Problem()
+	answer := p.Choices[picked]
+	if !answer.Equal(a.Answer) {
+		w.answeredWrong(p, a, answer.String())
+		p.ShootBan = Ticks(500 * time.Millisecond)
+		return
+	}
+	if p.Ammo == 0 {
+		w.reload(p, answer.String())
+	} else {
+		w.shoot(p, nil, answer.String())
+	}
+}
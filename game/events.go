@@ -0,0 +1,125 @@
+package game
+
+import (
+	"time"
+
+	"github.com/gonutz/ld41/mathgen"
+)
+
+// Event is something that happened in a game. The World publishes its events
+// on its Bus instead of calling the systems that care about them, like sound,
+// achievements and the visual effects, directly. Subscribers find out what
+// happened with a type switch.
+type Event interface {
+	event()
+}
+
+// AnswerSubmitted is sent for every answer to a problem, Correct is false for a
+// wrong answer. The Assignment is empty if it is not known which problem the
+// player tried to solve.
+type AnswerSubmitted struct {
+	Player     int
+	Assignment mathgen.Assignment
+	Answer     string // what the player answered
+	Correct    bool
+	AnswerTime int // ticks since the player's last correct answer
+}
+
+// ShotFired is sent once for each shot, even if the shotgun fires several
+// pellets.
+type ShotFired struct {
+	Player int
+}
+
+type GunReloaded struct {
+	Player int
+}
+
+type ZombieKilled struct {
+	Player int // who shot the zombie
+	Zombie Zombie
+}
+
+// PlayerHit is sent when a zombie reaches a player. The player then gives up,
+// which is PlayerGaveUp, and dies a moment later, which is PlayerDied. After
+// that, PlayerBled comes every now and then.
+type PlayerHit struct {
+	Player int
+}
+
+type PlayerGaveUp struct {
+	Player int
+}
+
+type PlayerDied struct {
+	Player int
+}
+
+type PlayerBled struct {
+	Player int
+}
+
+type PowerUpCollected struct {
+	Player int
+	Kind   PowerUpKind
+}
+
+// ComboChanged is sent when the player's multiplier grows or, with a
+// Multiplier of 1, when the combo is lost.
+type ComboChanged struct {
+	Player     int
+	Multiplier int
+}
+
+// TimePenalty is sent when a wrong answer costs time in a sprint.
+type TimePenalty struct {
+	Player int
+	Time   time.Duration
+}
+
+// ChoiceMoved is sent when the player selects another sign in the
+// MultipleChoice mode.
+type ChoiceMoved struct {
+	Player int
+}
+
+type SecondPassed struct {
+	Seconds int // since the game started
+}
+
+// RunEnded is the last event of a game.
+type RunEnded struct {
+	Seconds int
+	Score   int
+	Kills   int
+}
+
+func (AnswerSubmitted) event()  {}
+func (ShotFired) event()        {}
+func (GunReloaded) event()      {}
+func (ZombieKilled) event()     {}
+func (PlayerHit) event()        {}
+func (PlayerGaveUp) event()     {}
+func (PlayerDied) event()       {}
+func (PlayerBled) event()       {}
+func (PowerUpCollected) event() {}
+func (ComboChanged) event()     {}
+func (TimePenalty) event()      {}
+func (ChoiceMoved) event()      {}
+func (SecondPassed) event()     {}
+func (RunEnded) event()         {}
+
+// Bus passes the events to all subscribers in the order that they subscribed.
+type Bus struct {
+	subscribers []func(Event)
+}
+
+func (b *Bus) Subscribe(f func(Event)) {
+	b.subscribers = append(b.subscribers, f)
+}
+
+func (b *Bus) Publish(e Event) {
+	for _, f := range b.subscribers {
+		f(e)
+	}
+}
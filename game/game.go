@@ -0,0 +1,512 @@
+// Package game is the simulation of a No-Brain Jogging game: the players,
+// zombies, bullets and power-ups and the rules that move them. It advances in
+// fixed steps of Tick and knows nothing about windows, sound or drawing, so it
+// also runs without a window, e.g. in tests or to simulate many games quickly.
+package game
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/gonutz/ld41/mathgen"
+)
+
+const (
+	Width, Height  = 1200, 600 // size of the scene
+	TicksPerSecond = 60
+	Tick           = time.Second / TicksPerSecond // the time that one Step simulates
+
+	PlayerW, PlayerH   = 172, 207
+	PlayerHeadH        = 60
+	BulletShootOffsetY = 103
+	BulletW, BulletH   = 27, 9
+	ZombieW, ZombieH   = 116, 218
+	GroundY            = Height - 100 // where the players' feet are
+	MagazineSize       = 6
+	playerSpeed        = 4
+	playerWalkFrames   = 4
+	zombieFrameCount   = 4
+	zombieKindCount    = 3
+	sprintMissPenalty  = 3 * time.Second
+	sprintSpawnDelay   = 1200 * time.Millisecond
+)
+
+// Balance are the numbers that decide how fast the zombies come. The game
+// starts with a random delay between SpawnMin and SpawnMax until the next
+// zombie. Each kill multiplies the smallest delay by SpawnReduction and every
+// other kill also the largest one.
+type Balance struct {
+	SpawnMin, SpawnMax time.Duration
+	SpawnReduction     float32
+	ZombieSpeed        int // distance that zombies walk in each step
+	// PowerUpDrops decides what killed zombies drop. Each entry is the chance
+	// in percent that a zombie drops that kind of power-up, the chances must
+	// not add up to more than 100.
+	PowerUpDrops [PowerUpKindCount]int
+}
+
+// DefaultBalance is what the game uses unless the Settings say otherwise.
+var DefaultBalance = Balance{
+	SpawnMin:       1000 * time.Millisecond,
+	SpawnMax:       2000 * time.Millisecond,
+	SpawnReduction: 0.97,
+	ZombieSpeed:    2,
+	PowerUpDrops: [PowerUpKindCount]int{
+		PiercingBullets: 4,
+		ShotgunSpread:   4,
+		SlowMotion:      3,
+		FreeAnswer:      5,
+	},
+}
+
+// Ticks returns the number of steps that take d.
+func Ticks(d time.Duration) int {
+	return int(TicksPerSecond * d / time.Second)
+}
+
+// Source creates the problems that the players have to solve.
+// mathgen.Generator is a Source.
+type Source interface {
+	Generate(rand func() int) mathgen.Assignment
+}
+
+// nextAssignment returns a new assignment that is different from old, unless
+// the source has nothing else to offer.
+func nextAssignment(g Source, old mathgen.Assignment, rand func() int) mathgen.Assignment {
+	for i := 0; i < 100; i++ {
+		if a := g.Generate(rand); a != old {
+			return a
+		}
+	}
+	return old
+}
+
+// Mode decides how the players answer their problems.
+type Mode int
+
+const (
+	Classic        Mode = iota // type the answer to the problem above the hero
+	ZombieProblems             // every zombie carries its own problem
+	MultipleChoice             // pick the answer from a few signs
+	ModeCount
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Classic:
+		return "Classic"
+	case ZombieProblems:
+		return "Zombie Problems"
+	case MultipleChoice:
+		return "Multiple Choice"
+	default:
+		panic("invalid Mode")
+	}
+}
+
+// Settings are chosen before the game starts.
+type Settings struct {
+	Players int           // 2 for co-op games
+	Seed    int64         // for zombies and assignments
+	Sprint  time.Duration // length of a sprint, 0 for the survival game
+	Mode    Mode
+	Choices int // number of signs in the MultipleChoice mode, 4 if 0
+	// Shots and Reloads create the problems to solve for shooting and for
+	// reloading.
+	Shots, Reloads Source
+	Balance        Balance // DefaultBalance if empty
+}
+
+// Input is what the players did since the last step, one PlayerInput for each
+// player.
+type Input struct {
+	Players []PlayerInput
+}
+
+type PlayerInput struct {
+	Left, Right bool     // held down
+	Digits      [10]bool // digit keys that were pressed
+	Chars       []rune   // the digits, decimal points and slashes typed, in order
+	Token       bool     // use a free answer
+	// these move between and pick the signs in the MultipleChoice mode,
+	// PickChoice also submits a typed reload answer in the other modes
+	PrevChoice, NextChoice, PickChoice bool
+	ClickedChoice                      int // 1 + the index of a clicked sign, 0 for none
+}
+
+func (in Input) player(i int) PlayerInput {
+	if i < len(in.Players) {
+		return in.Players[i]
+	}
+	return PlayerInput{}
+}
+
+// World is the state of a running game.
+type World struct {
+	Settings    Settings
+	Players     []Player
+	Bullets     []Bullet
+	Zombies     []Zombie
+	Pickups     []Pickup
+	PowerUpTime [PowerUpKindCount]int // time left for each timed power-up
+	TimeLeft    int                   // until the sprint ends
+	Elapsed     int                   // time since the game started
+	Events      Bus
+
+	nextZombie       int // time until next zombie spawns
+	zombieSpawnDelay struct {
+		minFrames, maxFrames float32
+	}
+	rand         *rand.Rand // spawns zombies and drops power-ups
+	source       *source    // of rand
+	lastZombieID int
+}
+
+type Bullet struct {
+	X, Y         int
+	PrevX, PrevY int // the position before the last step
+	DX, dy       int
+	reach        int // distance left to fly
+	pierce       int // number of zombies the bullet can still pass through
+	shooter      int // index of the player who shot the bullet
+	target       int // id of the zombie that the bullet was fired at, 0 for any
+}
+
+type Zombie struct {
+	X, Y       int
+	PrevX      int // the position before the last step
+	FacingLeft bool
+	Frame      int
+	nextFrame  int
+	Kind       int
+	ID         int // unique in a game, starting at 1
+	// Assignment is the zombie's own problem in the ZombieProblems mode,
+	// Targeted is set while a bullet fired at the zombie is flying
+	Assignment mathgen.Assignment
+	Targeted   bool
+}
+
+// New starts a game.
+func New(settings Settings) *World {
+	if settings.Choices == 0 {
+		settings.Choices = 4
+	}
+	if settings.Balance == (Balance{}) {
+		settings.Balance = DefaultBalance
+	}
+	w := &World{Settings: settings}
+	seed := settings.Seed
+	if settings.Players == 2 {
+		w.Players = []Player{
+			newPlayer(Width/3-PlayerW/2, false, seed),
+			newPlayer(2*Width/3-PlayerW/2, true, seed+1),
+		}
+	} else {
+		w.Players = []Player{newPlayer((Width-PlayerW)/2, false, seed)}
+	}
+	w.rand, w.source = newRand(seed)
+	for i := range w.Players {
+		w.Players[i].setGenerators(settings.Shots, settings.Reloads)
+	}
+	w.zombieSpawnDelay.minFrames = float32(Ticks(settings.Balance.SpawnMin))
+	w.zombieSpawnDelay.maxFrames = float32(Ticks(settings.Balance.SpawnMax))
+	if settings.Sprint != 0 {
+		w.zombieSpawnDelay.minFrames = float32(Ticks(sprintSpawnDelay))
+		w.zombieSpawnDelay.maxFrames = float32(Ticks(sprintSpawnDelay)) + 1
+	}
+	w.TimeLeft = Ticks(settings.Sprint)
+	w.newZombie()
+	w.updateChoices()
+	return w
+}
+
+// Step advances the game by one Tick.
+func (w *World) Step(in Input) {
+	for i := range w.Players {
+		w.Players[i].PrevX = w.Players[i].X
+	}
+	for i := range w.Bullets {
+		w.Bullets[i].PrevX, w.Bullets[i].PrevY = w.Bullets[i].X, w.Bullets[i].Y
+	}
+	for i := range w.Zombies {
+		w.Zombies[i].PrevX = w.Zombies[i].X
+	}
+	if !w.TimeUp() {
+		for i := range w.Players {
+			w.handleInput(&w.Players[i], in.player(i))
+		}
+	}
+	if w.Settings.Sprint != 0 && w.TimeLeft > 0 {
+		w.TimeLeft--
+	}
+	if !w.AllDying() && !w.TimeUp() {
+		w.Elapsed++
+		if w.Elapsed%TicksPerSecond == 0 {
+			w.Events.Publish(SecondPassed{Seconds: w.Elapsed / TicksPerSecond})
+		}
+	}
+	w.moveBullets()
+	w.updatePowerUps()
+	if !w.AllDying() && !w.TimeUp() {
+		w.moveZombies()
+	}
+	for i := range w.Players {
+		w.animate(&w.Players[i])
+	}
+	w.updateChoices()
+}
+
+// End publishes the RunEnded event, call it when the game is left.
+func (w *World) End() {
+	w.Events.Publish(RunEnded{
+		Seconds: w.Elapsed / TicksPerSecond,
+		Score:   w.Score(),
+		Kills:   w.Kills(),
+	})
+}
+
+func (w *World) moveBullets() {
+	n := 0
+	for i := range w.Bullets {
+		b := &w.Bullets[i]
+		bulletHitbox := rectangle{
+			x: b.X,
+			y: b.Y,
+			w: BulletW + abs(b.DX),
+			h: BulletH,
+		}
+		if b.DX < 0 {
+			bulletHitbox.x += b.DX
+		}
+		b.X += b.DX
+		b.Y += b.dy
+		b.reach -= abs(b.DX)
+		// a bullet that was fired at a zombie passes through all others,
+		// unless that zombie is already dead
+		if b.target != 0 && w.zombieIndex(b.target) == -1 {
+			b.target = 0
+		}
+		victimIndex := -1
+		for i, z := range w.Zombies {
+			if b.target != 0 && z.ID != b.target {
+				continue
+			}
+			hitbox := rectangle{
+				x: z.X + ZombieW/4,
+				y: z.Y,
+				w: ZombieW / 2,
+				h: ZombieH,
+			}
+			if overlap(bulletHitbox, hitbox) {
+				if victimIndex == -1 ||
+					(b.DX > 0 && z.X < w.Zombies[victimIndex].X) ||
+					(b.DX < 0 && z.X > w.Zombies[victimIndex].X) {
+					victimIndex = i
+				}
+			}
+		}
+		hit := victimIndex != -1
+		if hit {
+			w.killZombie(victimIndex, &w.Players[b.shooter])
+		}
+		if hit && b.pierce > 0 {
+			b.pierce--
+			b.target = 0
+			hit = false
+		}
+		if !hit && b.reach > 0 && (-100 <= b.X) && (b.X <= Width+100) {
+			w.Bullets[n] = *b
+			n++
+		}
+	}
+	w.Bullets = w.Bullets[:n]
+	w.untargetMissedZombies()
+}
+
+// untargetMissedZombies lets the player shoot again at zombies that all bullets
+// fired at them missed, e.g. because the shotgun's pellets ran out of reach.
+func (w *World) untargetMissedZombies() {
+	for i := range w.Zombies {
+		z := &w.Zombies[i]
+		if !z.Targeted {
+			continue
+		}
+		missed := true
+		for _, b := range w.Bullets {
+			if b.target == z.ID {
+				missed = false
+				break
+			}
+		}
+		if missed {
+			z.Targeted = false
+		}
+	}
+}
+
+func (w *World) moveZombies() {
+	// in slow motion, zombies only walk every other frame
+	slow := w.PowerUpActive(SlowMotion)
+	frozen := slow && w.PowerUpTime[SlowMotion]%2 == 0
+	if !frozen {
+		w.nextZombie--
+	}
+	if w.nextZombie <= 0 {
+		w.newZombie()
+	}
+	for i := range w.Zombies {
+		z := &w.Zombies[i]
+		if frozen {
+			continue
+		}
+		if z.FacingLeft {
+			z.X -= w.Settings.Balance.ZombieSpeed
+		} else {
+			z.X += w.Settings.Balance.ZombieSpeed
+		}
+		const hitDist = 40
+		for i := range w.Players {
+			p := &w.Players[i]
+			if w.Settings.Sprint == 0 && !Dying(p.Torso) && abs((p.X+PlayerW/2)-(z.X+ZombieW/2)) < hitDist {
+				w.Events.Publish(PlayerHit{Player: i})
+				p.Torso = Realizing
+				p.torsoTime = Ticks(time.Second)
+			}
+		}
+		z.nextFrame--
+		if z.nextFrame <= 0 {
+			z.nextFrame = Ticks(250 * time.Millisecond)
+			z.Frame = (z.Frame + 1) % zombieFrameCount
+		}
+	}
+	// zombies that walked through the screen are gone
+	n := 0
+	for _, z := range w.Zombies {
+		if -ZombieW <= z.X && z.X <= Width {
+			w.Zombies[n] = z
+			n++
+		}
+	}
+	w.Zombies = w.Zombies[:n]
+}
+
+// killZombie removes the zombie and counts the kill for the player who shot it.
+// The points were already awarded for the answer that fired the bullet.
+func (w *World) killZombie(i int, p *Player) {
+	z := w.Zombies[i]
+	w.dropPowerUp(z.X + ZombieW/2)
+	copy(w.Zombies[i:], w.Zombies[i+1:])
+	w.Zombies = w.Zombies[:len(w.Zombies)-1]
+	p.Kills++
+	w.Events.Publish(ZombieKilled{Player: w.playerIndex(p), Zombie: z})
+	if w.Settings.Sprint != 0 {
+		// zombies come at a steady rate in a sprint
+		return
+	}
+	min, max := w.zombieSpawnDelay.minFrames, w.zombieSpawnDelay.maxFrames
+	reduction := w.Settings.Balance.SpawnReduction
+	w.zombieSpawnDelay.minFrames = min * reduction
+	if w.Kills()%2 == 1 {
+		w.zombieSpawnDelay.maxFrames = max * reduction
+	}
+}
+
+// newZombie spawns a zombie and sets the time until the next one comes.
+func (w *World) newZombie() {
+	w.SpawnZombie()
+	min := round(w.zombieSpawnDelay.minFrames)
+	max := round(w.zombieSpawnDelay.maxFrames)
+	if max <= min {
+		max = min + 1
+	}
+	w.nextZombie = min + w.rand.Intn(max-min)
+}
+
+// SpawnZombie lets a zombie come in from the left or right, without changing
+// when the next one comes.
+func (w *World) SpawnZombie() {
+	var z Zombie
+	z.FacingLeft = w.rand.Intn(2) == 0
+	z.Y = GroundY - ZombieH - 10 + w.rand.Intn(30)
+	if z.FacingLeft {
+		z.X = Width
+	} else {
+		z.X = -ZombieW
+	}
+	z.PrevX = z.X
+	z.Kind = w.rand.Intn(zombieKindCount)
+	w.lastZombieID++
+	z.ID = w.lastZombieID
+	if w.Settings.Mode == ZombieProblems {
+		z.Assignment = w.newZombieProblem()
+	}
+	w.Zombies = append(w.Zombies, z)
+}
+
+// newZombieProblem creates a problem whose answer no zombie on the screen has,
+// if possible, so the players can tell which zombie they shoot at.
+func (w *World) newZombieProblem() mathgen.Assignment {
+	var a mathgen.Assignment
+	for try := 0; try < 100; try++ {
+		a = w.Settings.Shots.Generate(w.rand.Int)
+		taken := false
+		for _, z := range w.Zombies {
+			taken = taken || !z.Targeted && z.Assignment.Answer.Equal(a.Answer)
+		}
+		if !taken {
+			break
+		}
+	}
+	return a
+}
+
+func (w *World) zombieIndex(id int) int {
+	for i := range w.Zombies {
+		if w.Zombies[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (w *World) playerIndex(p *Player) int {
+	for i := range w.Players {
+		if &w.Players[i] == p {
+			return i
+		}
+	}
+	panic("player not in game")
+}
+
+func (w *World) TimeUp() bool {
+	return w.Settings.Sprint != 0 && w.TimeLeft <= 0
+}
+
+// AllDying reports whether no player is left alive, this is when the game is
+// over.
+func (w *World) AllDying() bool {
+	for _, p := range w.Players {
+		if !Dying(p.Torso) {
+			return false
+		}
+	}
+	return true
+}
+
+// Score is the sum of all players' points.
+func (w *World) Score() int {
+	sum := 0
+	for _, p := range w.Players {
+		sum += p.Score
+	}
+	return sum
+}
+
+// Kills is the sum of all players' kills.
+func (w *World) Kills() int {
+	sum := 0
+	for _, p := range w.Players {
+		sum += p.Kills
+	}
+	return sum
+}
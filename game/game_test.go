@@ -0,0 +1,196 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gonutz/ld41/mathgen"
+)
+
+func newTestGame(seed int64) *World {
+	return New(Settings{
+		Players: 1,
+		Seed:    seed,
+		Shots:   mathgen.Normal.Shots(),
+		Reloads: mathgen.Normal.Reloads(),
+	})
+}
+
+// answer turns the player towards the closest zombie and types the correct
+// answer to their current problem.
+func answer(w *World) PlayerInput {
+	var in PlayerInput
+	p := &w.Players[0]
+	if i := w.closestZombie(p, nil); i != -1 {
+		left := w.Zombies[i].X < p.X
+		in.Left = left && !p.FacingLeft
+		in.Right = !left && p.FacingLeft
+	}
+	if p.Ammo == 0 {
+		in.Chars = []rune(p.ReloadAssignment.Answer.String())
+	} else {
+		in.Digits[p.Assignment.Answer.Int()] = true
+	}
+	return in
+}
+
+func TestIdlePlayerIsEaten(t *testing.T) {
+	w := newTestGame(1)
+	for i := 0; i < Ticks(time.Minute) && !w.AllDying(); i++ {
+		w.Step(Input{})
+	}
+	if !w.AllDying() {
+		t.Error("the player survived a minute without doing anything")
+	}
+	if w.Kills() != 0 {
+		t.Errorf("%d zombies were killed without a shot", w.Kills())
+	}
+}
+
+func TestAnsweringShootsZombies(t *testing.T) {
+	w := newTestGame(1)
+	var events []Event
+	w.Events.Subscribe(func(e Event) { events = append(events, e) })
+	for i := 0; i < Ticks(10*time.Second); i++ {
+		var in Input
+		if i%Ticks(time.Second/2) == 0 {
+			in.Players = []PlayerInput{answer(w)}
+		}
+		w.Step(in)
+	}
+	if w.AllDying() {
+		t.Fatal("the player died although they answered every half second")
+	}
+	if w.Kills() == 0 || w.Score() == 0 {
+		t.Errorf("kills %d, score %d, want both > 0", w.Kills(), w.Score())
+	}
+	kills := 0
+	for _, e := range events {
+		if _, ok := e.(ZombieKilled); ok {
+			kills++
+		}
+	}
+	if kills != w.Kills() {
+		t.Errorf("%d ZombieKilled events for %d kills", kills, w.Kills())
+	}
+}
+
+func TestSameSeedAndInputGiveTheSameGame(t *testing.T) {
+	a, b := newTestGame(5), newTestGame(5)
+	for i := 0; i < Ticks(20*time.Second); i++ {
+		var in Input
+		if i%Ticks(time.Second) == 0 {
+			in.Players = []PlayerInput{answer(a)}
+		}
+		a.Step(in)
+		b.Step(in)
+	}
+	if a.Score() != b.Score() || a.Kills() != b.Kills() || len(a.Zombies) != len(b.Zombies) {
+		t.Errorf("games differ: score %d/%d, kills %d/%d, zombies %d/%d",
+			a.Score(), b.Score(), a.Kills(), b.Kills(), len(a.Zombies), len(b.Zombies))
+	}
+}
+
+func TestShotgunScoresTheAnswerOnce(t *testing.T) {
+	w := newTestGame(1)
+	w.PowerUpTime[ShotgunSpread] = Ticks(time.Second)
+	w.PowerUpTime[PiercingBullets] = Ticks(time.Second)
+	p := &w.Players[0]
+	z := w.Zombies[0]
+	z.FacingLeft = true
+	w.Zombies = nil
+	for i := 0; i < 3; i++ {
+		z.X = p.X + 200 + 50*i
+		z.ID = i + 1
+		w.Zombies = append(w.Zombies, z)
+	}
+	w.Step(Input{Players: []PlayerInput{answer(w)}})
+	score := w.Score()
+	if score == 0 {
+		t.Fatal("the answer scored no points")
+	}
+	for i := 0; i < Ticks(time.Second/2); i++ {
+		w.Step(Input{})
+	}
+	if w.Kills() < 2 {
+		t.Fatalf("the shot killed %d zombies, want several", w.Kills())
+	}
+	if w.Score() != score {
+		t.Errorf("the score went from %d to %d, one answer must only score once", score, w.Score())
+	}
+}
+
+func TestZombieOutOfShotgunReachCanBeShotAgain(t *testing.T) {
+	w := New(Settings{
+		Players: 1,
+		Seed:    1,
+		Mode:    ZombieProblems,
+		Shots:   mathgen.Normal.Shots(),
+		Reloads: mathgen.Normal.Reloads(),
+	})
+	w.PowerUpTime[ShotgunSpread] = Ticks(time.Second)
+	p := &w.Players[0]
+	p.X = 0
+	z := w.Zombies[0]
+	z.X = Width - ZombieW
+	z.FacingLeft = true
+	w.Zombies = []Zombie{z}
+	w.shootZombie(p, 0)
+	for i := 0; i < Ticks(time.Second) && len(w.Bullets) > 0; i++ {
+		w.Step(Input{})
+	}
+	if len(w.Bullets) != 0 {
+		t.Fatal("the pellets are still flying after a second")
+	}
+	if w.Kills() != 0 {
+		t.Fatal("the pellets hit a zombie out of their reach")
+	}
+	if w.closestZombie(p, nil) != 0 {
+		t.Error("the missed zombie cannot be shot at again")
+	}
+}
+
+func TestBalanceDecidesThePowerUpDrops(t *testing.T) {
+	balance := DefaultBalance
+	balance.PowerUpDrops = [PowerUpKindCount]int{SlowMotion: 100}
+	w := New(Settings{
+		Players: 1,
+		Seed:    1,
+		Shots:   mathgen.Normal.Shots(),
+		Reloads: mathgen.Normal.Reloads(),
+		Balance: balance,
+	})
+	w.killZombie(0, &w.Players[0])
+	if len(w.Pickups) != 1 || w.Pickups[0].Kind != SlowMotion {
+		t.Errorf("a zombie that always drops slow motion dropped %v", w.Pickups)
+	}
+}
+
+func TestAnswersWithTheSameValueCount(t *testing.T) {
+	answer, err := mathgen.ParseNumber("3/4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, typed := range []string{"3/4", "6/8", "0.75", "12/16"} {
+		var in AnswerInput
+		complete := false
+		for _, char := range typed {
+			if complete {
+				t.Fatalf("%q was taken before it was typed completely", typed)
+			}
+			_, complete, _ = in.Add(char, answer)
+		}
+		if !complete {
+			t.Errorf("%q was not taken for 3/4", typed)
+		}
+	}
+	var in AnswerInput
+	for _, char := range "0.7" {
+		if _, complete, _ := in.Add(char, answer); complete {
+			t.Fatal("0.7 was taken for 3/4")
+		}
+	}
+	if typed, complete, correct := in.Submit(answer); typed != "0.7" || !complete || correct {
+		t.Errorf("submitting 0.7 for 3/4 gave %q, %v, %v", typed, complete, correct)
+	}
+}
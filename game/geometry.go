@@ -0,0 +1,23 @@
+package game
+
+type rectangle struct {
+	x, y, w, h int
+}
+
+func overlap(r, s rectangle) bool {
+	return s.x+s.w >= r.x && s.y+s.h >= r.y && s.x < r.x+r.w && s.y < r.y+r.h
+}
+
+func round(x float32) int {
+	if x >= 0 {
+		return int(x + 0.5)
+	}
+	return int(x - 0.5)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
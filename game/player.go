@@ -0,0 +1,357 @@
+package game
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/gonutz/ld41/mathgen"
+)
+
+type Torso int
+
+const (
+	Idle Torso = iota
+	Reloading
+	WaitingToReload
+	Shooting
+	Realizing
+	AimingAtHead
+	Bleeding
+)
+
+func Dying(t Torso) bool {
+	return t >= Realizing
+}
+
+// Player is one hero in the game. Each player has their own assignments and
+// score.
+type Player struct {
+	X, Y             int
+	PrevX            int // the position before the last step
+	FacingLeft       bool
+	Walking          bool
+	WalkFrame        int
+	walkTime         int
+	generator        Source
+	Assignment       mathgen.Assignment
+	Ammo             int
+	reloadGenerator  Source
+	ReloadAssignment mathgen.Assignment
+	ReloadInput      AnswerInput
+	Choices          []mathgen.Number   // the signs in the MultipleChoice mode
+	Choice           int                // the selected sign
+	choicesFor       mathgen.Assignment // the problem that the choices are for
+	ShootBan         int                // time until shooting is allowed after wrong number
+	Score            int                // points
+	Kills            int
+	streak           int // correct answers in a row
+	answerTime       int // time since the last correct answer
+	Tokens           int // free answers left
+	Torso            Torso
+	torsoTime        int
+	rand             *rand.Rand // creates the assignments
+	source           *source    // of rand
+}
+
+// newPlayer creates a player whose assignments are generated from the given
+// seed. The player has no assignments until setGenerators is called.
+func newPlayer(x int, facingLeft bool, seed int64) Player {
+	r, src := newRand(seed)
+	return Player{
+		X:          x,
+		Y:          GroundY - PlayerH,
+		PrevX:      x,
+		FacingLeft: facingLeft,
+		Ammo:       MagazineSize,
+		Torso:      Idle,
+		rand:       r,
+		source:     src,
+	}
+}
+
+// setGenerators changes where the player's assignments come from and replaces
+// the current ones.
+func (p *Player) setGenerators(shots, reloads Source) {
+	p.generator = shots
+	p.reloadGenerator = reloads
+	p.Assignment = p.generator.Generate(p.rand.Int)
+	p.ReloadAssignment = p.reloadGenerator.Generate(p.rand.Int)
+}
+
+// Problem is the assignment that the player solves next, the reload assignment
+// once the gun is empty.
+func (p *Player) Problem() mathgen.Assignment {
+	if p.Ammo == 0 {
+		return p.ReloadAssignment
+	}
+	return p.Assignment
+}
+
+// Neck is where the blood comes out once the player is dead.
+func (p *Player) Neck() (x, y int) {
+	dx := -6
+	if p.FacingLeft {
+		dx = -dx
+	}
+	return p.X + PlayerW/2 + dx, p.Y + PlayerHeadH
+}
+
+func (p *Player) hitbox() rectangle {
+	return rectangle{
+		x: p.X + PlayerW/4,
+		y: p.Y,
+		w: PlayerW / 2,
+		h: PlayerH,
+	}
+}
+
+// handleInput lets the player shoot, reload and walk.
+func (w *World) handleInput(p *Player, in PlayerInput) {
+	// shoot or miss
+	p.ShootBan--
+	if p.ShootBan < 0 {
+		p.ShootBan = 0
+	}
+	if !Dying(p.Torso) {
+		p.answerTime++
+	}
+	if !Dying(p.Torso) && p.ShootBan <= 0 && p.Tokens > 0 && in.Token {
+		w.useToken(p)
+	}
+	if !Dying(p.Torso) && p.ShootBan <= 0 {
+		switch {
+		case p.Ammo > 0 && p.Torso == Reloading:
+			// wait until the gun is loaded
+		case w.Settings.Mode == MultipleChoice:
+			w.pickChoice(p, in)
+		case p.Ammo == 0:
+			w.typeReloadAnswer(p, in)
+		case w.Settings.Mode == ZombieProblems:
+			w.typeZombieAnswer(p, in)
+		default:
+			w.typeShotAnswer(p, in)
+		}
+	}
+	// move left/right
+	p.Walking = false
+	if !Dying(p.Torso) {
+		const margin = -50
+		if in.Left {
+			p.Walking = true
+			p.X -= playerSpeed
+			if p.X < margin {
+				p.X = margin
+			}
+			p.FacingLeft = true
+		} else if in.Right {
+			p.Walking = true
+			p.X += playerSpeed
+			if p.X+PlayerW > Width-margin {
+				p.X = Width - margin - PlayerW
+			}
+			p.FacingLeft = false
+		}
+	}
+	if p.Walking {
+		p.walkTime--
+		if p.walkTime <= 0 {
+			p.WalkFrame = (p.WalkFrame + 1) % playerWalkFrames
+			p.walkTime = Ticks(100 * time.Millisecond)
+		}
+	} else {
+		p.WalkFrame = 0
+		p.walkTime = 0
+	}
+}
+
+// animate advances the player's torso animation.
+func (w *World) animate(p *Player) {
+	if p.torsoTime > 0 {
+		p.torsoTime--
+		if p.torsoTime == 0 {
+			switch p.Torso {
+			case Idle:
+				// nothing to do in this case
+			case Shooting:
+				if p.Ammo > 0 {
+					p.Torso = Idle
+				} else {
+					// stays like this until the reload assignment is solved
+					p.Torso = WaitingToReload
+				}
+			case Reloading:
+				p.Torso = Idle
+			case WaitingToReload:
+				// nothing to do in this case
+			case Realizing:
+				p.Torso = AimingAtHead
+				p.torsoTime = Ticks(time.Second)
+				w.Events.Publish(PlayerGaveUp{Player: w.playerIndex(p)})
+			case AimingAtHead:
+				p.Torso = Bleeding
+				p.torsoTime = Ticks(50 * time.Millisecond)
+				w.Events.Publish(PlayerDied{Player: w.playerIndex(p)})
+			case Bleeding:
+				p.torsoTime = Ticks(50 * time.Millisecond)
+				w.Events.Publish(PlayerBled{Player: w.playerIndex(p)})
+			}
+		}
+	}
+}
+
+// shoot fires the player's gun. If target is nil, the bullet was earned by
+// solving the player's assignment. Otherwise it was earned by solving the
+// target's problem and only hits that zombie. answer is what the player
+// answered.
+func (w *World) shoot(p *Player, target *Zombie, answer string) {
+	w.Events.Publish(ShotFired{Player: w.playerIndex(p)})
+	const bulletSpeed = 30
+	var b Bullet
+	b.Y = p.Y + BulletShootOffsetY
+	if p.FacingLeft {
+		b.X = p.X
+		b.DX = -bulletSpeed
+	} else {
+		b.X = p.X + PlayerW - BulletW
+		b.DX = bulletSpeed
+	}
+	b.PrevX, b.PrevY = b.X, b.Y
+	b.reach = Width + 200
+	b.shooter = w.playerIndex(p)
+	if target != nil {
+		p.Score += w.answeredCorrectly(p, target.Assignment, answer)
+		b.target = target.ID
+		target.Targeted = true
+	} else {
+		p.Score += w.answeredCorrectly(p, p.Assignment, answer)
+		p.Assignment = nextAssignment(p.generator, p.Assignment, p.rand.Int)
+	}
+	if w.PowerUpActive(PiercingBullets) {
+		b.pierce = piercingHits
+	}
+	if w.PowerUpActive(ShotgunSpread) {
+		b.reach = shotgunReach
+		for i := 0; i < shotgunPellets; i++ {
+			b.dy = i - shotgunPellets/2
+			w.Bullets = append(w.Bullets, b)
+		}
+	} else {
+		w.Bullets = append(w.Bullets, b)
+	}
+	p.Ammo--
+	p.Torso = Shooting
+	p.torsoTime = Ticks(100 * time.Millisecond)
+}
+
+// typeShotAnswer shoots if the player typed the answer to their assignment and
+// bans shooting for a while otherwise.
+func (w *World) typeShotAnswer(p *Player, in PlayerInput) {
+	wrongNumber := false
+	for n, pressed := range in.Digits {
+		if pressed {
+			if !mathgen.Whole(n).Equal(p.Assignment.Answer) {
+				wrongNumber = true
+				w.answeredWrong(p, p.Assignment, mathgen.Whole(n).String())
+				p.ShootBan = Ticks(500 * time.Millisecond)
+				break
+			}
+		}
+	}
+	// shot answers are always single digits
+	if answer := p.Assignment.Answer.Int(); !wrongNumber && 0 <= answer && answer <= 9 {
+		if in.Digits[answer] {
+			w.shoot(p, nil, p.Assignment.Answer.String())
+		}
+	}
+}
+
+// typeReloadAnswer reads the characters of the reload answer. The gun is
+// reloaded once the player typed the answer. If the player submits something
+// else, the input is discarded as a miss.
+func (w *World) typeReloadAnswer(p *Player, in PlayerInput) {
+	for _, char := range in.Chars {
+		if typed, complete, _ := p.ReloadInput.Add(char, p.ReloadAssignment.Answer); complete {
+			w.reload(p, typed)
+			return
+		}
+	}
+	if !in.PickChoice {
+		return
+	}
+	typed, complete, correct := p.ReloadInput.Submit(p.ReloadAssignment.Answer)
+	if complete && correct {
+		w.reload(p, typed)
+	} else if complete {
+		w.answeredWrong(p, p.ReloadAssignment, typed)
+		p.ShootBan = Ticks(time.Second)
+	}
+}
+
+// useToken spends a free answer token to solve the current assignment.
+func (w *World) useToken(p *Player) {
+	if p.Ammo == 0 {
+		p.Tokens--
+		p.ReloadInput.Clear()
+		w.reload(p, p.ReloadAssignment.Answer.String())
+	} else if p.Torso != Reloading && w.Settings.Mode == ZombieProblems {
+		if i := w.closestZombie(p, nil); i != -1 {
+			p.Tokens--
+			w.shootZombie(p, i)
+		}
+	} else if p.Torso != Reloading {
+		p.Tokens--
+		w.shoot(p, nil, p.Assignment.Answer.String())
+	}
+}
+
+// typeZombieAnswer shoots at the zombie whose problem has the typed answer. If
+// no zombie has this answer, the player missed.
+func (w *World) typeZombieAnswer(p *Player, in PlayerInput) {
+	for digit, pressed := range in.Digits {
+		if !pressed {
+			continue
+		}
+		answer := mathgen.Whole(digit)
+		if i := w.closestZombie(p, &answer); i != -1 {
+			w.shootZombie(p, i)
+		} else {
+			// there is no telling which problem the player got wrong
+			w.answeredWrong(p, mathgen.Assignment{}, answer.String())
+			p.ShootBan = Ticks(500 * time.Millisecond)
+		}
+		return
+	}
+}
+
+// shootZombie turns the player towards the i'th zombie and fires at it.
+func (w *World) shootZombie(p *Player, i int) {
+	z := &w.Zombies[i]
+	p.FacingLeft = z.X+ZombieW/2 < p.X+PlayerW/2
+	w.shoot(p, z, z.Assignment.Answer.String())
+}
+
+// closestZombie returns the index of the zombie closest to the player that is
+// not shot at yet. If answer is not nil, only zombies whose problem has this
+// answer are considered. This decides which zombie is shot if several of them
+// share the answer. -1 means there is no such zombie.
+func (w *World) closestZombie(p *Player, answer *mathgen.Number) int {
+	closest := -1
+	for i, z := range w.Zombies {
+		if z.Targeted || answer != nil && !z.Assignment.Answer.Equal(*answer) {
+			continue
+		}
+		if closest == -1 || abs(z.X-p.X) < abs(w.Zombies[closest].X-p.X) {
+			closest = i
+		}
+	}
+	return closest
+}
+
+func (w *World) reload(p *Player, answer string) {
+	w.Events.Publish(GunReloaded{Player: w.playerIndex(p)})
+	p.Score += w.answeredCorrectly(p, p.ReloadAssignment, answer)
+	p.Ammo = MagazineSize
+	p.ReloadAssignment = nextAssignment(p.reloadGenerator, p.ReloadAssignment, p.rand.Int)
+	p.Torso = Reloading
+	p.torsoTime = Ticks(400 * time.Millisecond)
+}
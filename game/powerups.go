@@ -0,0 +1,100 @@
+package game
+
+import "time"
+
+const (
+	PowerUpW, PowerUpH = 40, 40
+	powerUpLifeTime    = 8 * time.Second
+	PowerUpBlinkTime   = 2 * time.Second
+	piercingHits       = 3 // number of extra zombies a piercing bullet kills
+	shotgunPellets     = 3
+	shotgunReach       = 450
+)
+
+type PowerUpKind int
+
+const (
+	PiercingBullets PowerUpKind = iota
+	ShotgunSpread
+	SlowMotion
+	FreeAnswer
+	PowerUpKindCount
+)
+
+// PowerUpDurations are how long each kind of power-up lasts. Kinds with a
+// duration of 0 are not timed but give the player a token which is used up
+// later.
+var PowerUpDurations = [PowerUpKindCount]time.Duration{
+	PiercingBullets: 10 * time.Second,
+	ShotgunSpread:   8 * time.Second,
+	SlowMotion:      6 * time.Second,
+	FreeAnswer:      0,
+}
+
+// Pickup is a power-up lying on the ground, it disappears once its Life is
+// over.
+type Pickup struct {
+	Kind PowerUpKind
+	X, Y int
+	Life int
+}
+
+// dropPowerUp rolls the Balance's drop table and maybe places a pickup on the
+// ground where the zombie at x died.
+func (w *World) dropPowerUp(x int) {
+	r := w.rand.Intn(100)
+	for kind, percent := range w.Settings.Balance.PowerUpDrops {
+		if r < percent {
+			w.Pickups = append(w.Pickups, Pickup{
+				Kind: PowerUpKind(kind),
+				X:    x - PowerUpW/2,
+				Y:    GroundY - PowerUpH,
+				Life: Ticks(powerUpLifeTime),
+			})
+			return
+		}
+		r -= percent
+	}
+}
+
+func (w *World) updatePowerUps() {
+	for kind := range w.PowerUpTime {
+		if w.PowerUpTime[kind] > 0 {
+			w.PowerUpTime[kind]--
+		}
+	}
+	n := 0
+	for i := range w.Pickups {
+		p := &w.Pickups[i]
+		p.Life--
+		pickupHitbox := rectangle{x: p.X, y: p.Y, w: PowerUpW, h: PowerUpH}
+		collected := false
+		for i := range w.Players {
+			player := &w.Players[i]
+			if !collected && !Dying(player.Torso) && overlap(pickupHitbox, player.hitbox()) {
+				collected = true
+				w.collectPowerUp(player, p.Kind)
+			}
+		}
+		if !collected && p.Life > 0 {
+			w.Pickups[n] = *p
+			n++
+		}
+	}
+	w.Pickups = w.Pickups[:n]
+}
+
+// collectPowerUp activates the power-up for all players or, for untimed kinds,
+// gives a token to the player who picked it up.
+func (w *World) collectPowerUp(p *Player, kind PowerUpKind) {
+	w.Events.Publish(PowerUpCollected{Player: w.playerIndex(p), Kind: kind})
+	if PowerUpDurations[kind] == 0 {
+		p.Tokens++
+	} else {
+		w.PowerUpTime[kind] = Ticks(PowerUpDurations[kind])
+	}
+}
+
+func (w *World) PowerUpActive(kind PowerUpKind) bool {
+	return w.PowerUpTime[kind] > 0
+}
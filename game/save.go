@@ -0,0 +1,194 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+
+	"github.com/gonutz/ld41/mathgen"
+)
+
+// saveVersion changes whenever saved games of older versions cannot be loaded
+// anymore.
+const saveVersion = 2
+
+// source is the random source from math/rand, which cannot be saved, but it
+// counts how many numbers it produced. A new source with the same seed that
+// skips as many numbers is in the same state.
+type source struct {
+	seed  int64
+	count int
+	src   rand.Source
+}
+
+func newRand(seed int64) (*rand.Rand, *source) {
+	s := &source{seed: seed, src: rand.NewSource(seed)}
+	return rand.New(s), s
+}
+
+func (s *source) Int63() int64 {
+	s.count++
+	return s.src.Int63()
+}
+
+func (s *source) Seed(seed int64) {
+	s.seed = seed
+	s.count = 0
+	s.src.Seed(seed)
+}
+
+type savedRand struct {
+	Seed  int64
+	Count int
+}
+
+func (s *source) save() savedRand {
+	return savedRand{Seed: s.seed, Count: s.count}
+}
+
+func (s savedRand) load() (*rand.Rand, *source) {
+	src := &source{seed: s.Seed, src: rand.NewSource(s.Seed)}
+	for src.count < s.Count {
+		src.Int63()
+	}
+	return rand.New(src), src
+}
+
+// savedWorld has all of the World's state, the unexported parts too. The
+// Settings are saved without their Sources.
+type savedWorld struct {
+	Version      int
+	Settings     Settings
+	Players      []savedPlayer
+	Bullets      []savedBullet
+	Zombies      []savedZombie
+	Pickups      []Pickup
+	PowerUpTime  [PowerUpKindCount]int
+	TimeLeft     int
+	Elapsed      int
+	NextZombie   int
+	SpawnMin     float32
+	SpawnMax     float32
+	Rand         savedRand
+	LastZombieID int
+}
+
+type savedPlayer struct {
+	Player
+	WalkTime   int
+	ChoicesFor mathgen.Assignment
+	Streak     int
+	AnswerTime int
+	TorsoTime  int
+	Rand       savedRand
+}
+
+type savedBullet struct {
+	Bullet
+	DY, Reach, Pierce, Shooter, Target int
+}
+
+type savedZombie struct {
+	Zombie
+	NextFrame int
+}
+
+// Save writes the state of the game so Load can continue it later.
+func (w *World) Save() ([]byte, error) {
+	s := savedWorld{
+		Version:      saveVersion,
+		Settings:     w.Settings,
+		Pickups:      w.Pickups,
+		PowerUpTime:  w.PowerUpTime,
+		TimeLeft:     w.TimeLeft,
+		Elapsed:      w.Elapsed,
+		NextZombie:   w.nextZombie,
+		SpawnMin:     w.zombieSpawnDelay.minFrames,
+		SpawnMax:     w.zombieSpawnDelay.maxFrames,
+		Rand:         w.source.save(),
+		LastZombieID: w.lastZombieID,
+	}
+	s.Settings.Shots, s.Settings.Reloads = nil, nil
+	for _, p := range w.Players {
+		s.Players = append(s.Players, savedPlayer{
+			Player:     p,
+			WalkTime:   p.walkTime,
+			ChoicesFor: p.choicesFor,
+			Streak:     p.streak,
+			AnswerTime: p.answerTime,
+			TorsoTime:  p.torsoTime,
+			Rand:       p.source.save(),
+		})
+	}
+	for _, b := range w.Bullets {
+		s.Bullets = append(s.Bullets, savedBullet{
+			Bullet:  b,
+			DY:      b.dy,
+			Reach:   b.reach,
+			Pierce:  b.pierce,
+			Shooter: b.shooter,
+			Target:  b.target,
+		})
+	}
+	for _, z := range w.Zombies {
+		s.Zombies = append(s.Zombies, savedZombie{Zombie: z, NextFrame: z.nextFrame})
+	}
+	return json.Marshal(s)
+}
+
+// Load continues a game that was written by Save. The Sources are not saved,
+// shots and reloads have to be the ones that the game was started with.
+func Load(data []byte, shots, reloads Source) (*World, error) {
+	var s savedWorld
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if s.Version != saveVersion {
+		return nil, errors.New("the game was saved by another version")
+	}
+	if len(s.Players) == 0 {
+		return nil, errors.New("the saved game has no players")
+	}
+	w := &World{
+		Settings:     s.Settings,
+		Pickups:      s.Pickups,
+		PowerUpTime:  s.PowerUpTime,
+		TimeLeft:     s.TimeLeft,
+		Elapsed:      s.Elapsed,
+		nextZombie:   s.NextZombie,
+		lastZombieID: s.LastZombieID,
+	}
+	w.Settings.Shots, w.Settings.Reloads = shots, reloads
+	w.zombieSpawnDelay.minFrames = s.SpawnMin
+	w.zombieSpawnDelay.maxFrames = s.SpawnMax
+	w.rand, w.source = s.Rand.load()
+	for _, sp := range s.Players {
+		p := sp.Player
+		p.generator, p.reloadGenerator = shots, reloads
+		p.walkTime = sp.WalkTime
+		p.choicesFor = sp.ChoicesFor
+		p.streak = sp.Streak
+		p.answerTime = sp.AnswerTime
+		p.torsoTime = sp.TorsoTime
+		p.rand, p.source = sp.Rand.load()
+		w.Players = append(w.Players, p)
+	}
+	for _, sb := range s.Bullets {
+		b := sb.Bullet
+		b.dy = sb.DY
+		b.reach = sb.Reach
+		b.pierce = sb.Pierce
+		b.shooter = sb.Shooter
+		b.target = sb.Target
+		if b.shooter < 0 || b.shooter >= len(w.Players) {
+			return nil, errors.New("a saved bullet has no shooter")
+		}
+		w.Bullets = append(w.Bullets, b)
+	}
+	for _, sz := range s.Zombies {
+		z := sz.Zombie
+		z.nextFrame = sz.NextFrame
+		w.Zombies = append(w.Zombies, z)
+	}
+	return w, nil
+}
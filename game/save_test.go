@@ -0,0 +1,71 @@
+package game
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/gonutz/ld41/mathgen"
+)
+
+func TestSavedGameContinuesTheSame(t *testing.T) {
+	for _, mode := range []Mode{Classic, ZombieProblems, MultipleChoice} {
+		settings := Settings{
+			Players: 2,
+			Seed:    7,
+			Sprint:  time.Minute,
+			Mode:    mode,
+			Shots:   mathgen.Hard.Shots(),
+			Reloads: mathgen.Hard.Reloads(),
+		}
+		a := New(settings)
+		play := func(w *World, ticks int) {
+			for i := 0; i < ticks; i++ {
+				var in Input
+				if i%Ticks(time.Second/3) == 0 {
+					in.Players = []PlayerInput{answer(w), {Right: true}}
+					in.Players[0].ClickedChoice = 1 + i%3
+				}
+				w.Step(in)
+			}
+		}
+		play(a, Ticks(15*time.Second))
+
+		data, err := a.Save()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Load(data, settings.Shots, settings.Reloads)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again, _ := b.Save(); !bytes.Equal(again, data) {
+			t.Fatalf("%v: the loaded game is different:\n%s\n%s", mode, data, again)
+		}
+
+		play(a, Ticks(20*time.Second))
+		play(b, Ticks(20*time.Second))
+		dataA, _ := a.Save()
+		dataB, _ := b.Save()
+		if !bytes.Equal(dataA, dataB) {
+			t.Errorf("%v: the loaded game went on differently:\n%s\n%s", mode, dataA, dataB)
+		}
+		if a.Kills() == 0 || len(a.Zombies) == 0 {
+			t.Errorf("%v: kills %d, zombies %d, the test should have more going on",
+				mode, a.Kills(), len(a.Zombies))
+		}
+	}
+}
+
+func TestLoadRejectsBrokenSaves(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"{",
+		`{"Version":0}`,
+		`{"Version":1}`,
+	} {
+		if _, err := Load([]byte(data), mathgen.Easy.Shots(), mathgen.Easy.Reloads()); err == nil {
+			t.Errorf("loading %q did not fail", data)
+		}
+	}
+}
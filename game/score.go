@@ -0,0 +1,88 @@
+package game
+
+import (
+	"time"
+
+	"github.com/gonutz/ld41/mathgen"
+)
+
+const (
+	comboStep      = 5 // correct answers in a row needed per multiplier step
+	maxMultiplier  = 5
+	fastAnswerTime = 3 * time.Second // answers faster than this get a bonus
+	maxTimeBonus   = 10
+)
+
+// opPoints are the base points for solving an assignment, harder operations
+// are worth more.
+var opPoints = [mathgen.OpCount]int{
+	mathgen.Add:         10,
+	mathgen.Subtract:    10,
+	mathgen.Multiply:    20,
+	mathgen.Divide:      25,
+	mathgen.Power:       20,
+	mathgen.SquareRoot:  20,
+	mathgen.Modulo:      25,
+	mathgen.Fractions:   25,
+	mathgen.Decimals:    25,
+	mathgen.Binary:      20,
+	mathgen.Hexadecimal: 25,
+	mathgen.PlaceValue:  10,
+	mathgen.Roman:       15,
+	mathgen.Custom:      15,
+}
+
+func (p *Player) Multiplier() int {
+	m := 1 + p.streak/comboStep
+	if m > maxMultiplier {
+		m = maxMultiplier
+	}
+	return m
+}
+
+// answeredCorrectly extends the player's streak and returns the points that
+// the answer to a is worth. The points include the time bonus and the combo
+// multiplier.
+func (w *World) answeredCorrectly(p *Player, a mathgen.Assignment, answer string) int {
+	i := w.playerIndex(p)
+	w.Events.Publish(AnswerSubmitted{
+		Player:     i,
+		Assignment: a,
+		Answer:     answer,
+		Correct:    true,
+		AnswerTime: p.answerTime,
+	})
+	oldMultiplier := p.Multiplier()
+	p.streak++
+	if m := p.Multiplier(); m > oldMultiplier {
+		w.Events.Publish(ComboChanged{Player: i, Multiplier: m})
+	}
+	points := opPoints[a.Op]
+	if left := Ticks(fastAnswerTime) - p.answerTime; left > 0 {
+		points += maxTimeBonus * left / Ticks(fastAnswerTime)
+	}
+	p.answerTime = 0
+	return points * p.Multiplier()
+}
+
+// answeredWrong breaks the player's streak. In a sprint, it also costs time.
+func (w *World) answeredWrong(p *Player, a mathgen.Assignment, answer string) {
+	i := w.playerIndex(p)
+	w.Events.Publish(AnswerSubmitted{
+		Player:     i,
+		Assignment: a,
+		Answer:     answer,
+		AnswerTime: p.answerTime,
+	})
+	if p.Multiplier() > 1 {
+		w.Events.Publish(ComboChanged{Player: i, Multiplier: 1})
+	}
+	p.streak = 0
+	if w.Settings.Sprint != 0 {
+		w.TimeLeft -= Ticks(sprintMissPenalty)
+		if w.TimeLeft < 0 {
+			w.TimeLeft = 0
+		}
+		w.Events.Publish(TimePenalty{Player: i, Time: sprintMissPenalty})
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !js
+
+package main
+
+import "testing"
+
+// isolateStorage keeps the tests away from the player's files. On Windows the
+// files are in APPDATA, elsewhere they are next to the test binary.
+func isolateStorage(t *testing.T) {
+	t.Setenv("APPDATA", t.TempDir())
+}
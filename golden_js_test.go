@@ -0,0 +1,34 @@
+//go:build js
+
+package main
+
+import (
+	"syscall/js"
+	"testing"
+)
+
+// isolateStorage replaces the browser's localStorage with an empty one in
+// memory, Node.js has none.
+func isolateStorage(t *testing.T) {
+	items := make(map[string]string)
+	getItem := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if text, ok := items[args[0].String()]; ok {
+			return text
+		}
+		return nil
+	})
+	setItem := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		items[args[0].String()] = args[1].String()
+		return nil
+	})
+	storage := js.Global().Get("Object").New()
+	storage.Set("getItem", getItem)
+	storage.Set("setItem", setItem)
+	old := js.Global().Get("localStorage")
+	js.Global().Set("localStorage", storage)
+	t.Cleanup(func() {
+		js.Global().Set("localStorage", old)
+		getItem.Release()
+		setItem.Release()
+	})
+}
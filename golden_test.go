@@ -0,0 +1,281 @@
+package main
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gonutz/ld41/game"
+	"github.com/gonutz/ld41/mathgen"
+	"github.com/gonutz/ld41/offscreen"
+	"github.com/gonutz/prototype/draw"
+)
+
+// The golden tests draw screens of the game with the offscreen renderer and
+// compare them with the PNGs in testdata/golden. If a test fails, the frame
+// and an image of the differences are written to testdata/failed. Once a
+// change to the screens is intended, run
+//
+//	go test -run Golden -update
+//
+// and look at the new golden frames before committing them.
+var updateGolden = flag.Bool("update", false, "write the golden frames instead of comparing them")
+
+const (
+	goldenFolder = "testdata/golden"
+	failedFolder = "testdata/failed"
+	// colors that are this close count as the same, which allows for small
+	// differences in rounding
+	channelTolerance = 8
+	// the share of pixels that may differ before a frame is wrong
+	pixelTolerance = 0.001
+)
+
+func TestGoldenMenu(t *testing.T) {
+	window := newGoldenWindow(t)
+	menu.enter(nil)
+	run(window, menu, 1, nil)
+	checkGolden(t, window, "menu")
+}
+
+func TestGoldenInstructions(t *testing.T) {
+	window := newGoldenWindow(t)
+	instructions.enter(menu)
+	run(window, instructions, 1, nil)
+	checkGolden(t, window, "instructions")
+}
+
+// TestGoldenGameplay plays a few seconds of a seeded game.
+func TestGoldenGameplay(t *testing.T) {
+	window := newGoldenWindow(t)
+	startGame(7)
+	playScripted(t, window)
+	checkGolden(t, window, "gameplay")
+}
+
+// TestGoldenDeath shows the hero shortly after they shot themselves.
+func TestGoldenDeath(t *testing.T) {
+	window := newGoldenWindow(t)
+	startGame(7)
+	waitForDeath(t)
+	run(window, playing, frames(300*time.Millisecond), nil)
+	checkGolden(t, window, "death")
+}
+
+// TestGoldenHighScores enters a name for the new high score after the scripted
+// game.
+func TestGoldenHighScores(t *testing.T) {
+	window := newGoldenWindow(t)
+	saveHighScores(mainTable, []highscore{
+		{name: "Ada", score: 500},
+		{name: "Grace", score: 120},
+		{name: "Alan", score: 1},
+	})
+	startGame(7)
+	playScripted(t, window)
+	waitForDeath(t)
+	s := run(window, playing, frames(5*time.Second), nil)
+	if s != dead {
+		t.Fatal("the game did not end after the hero died")
+	}
+	run(window, dead, 1, func(int) {
+		window.Type("Zombie Slayer")
+	})
+	run(window, dead, 1, func(int) {
+		window.Press(draw.KeyEnter)
+	})
+	checkGolden(t, window, "highscores")
+}
+
+// TestGoldenSquareRoot draws the radical sign that the font does not have.
+func TestGoldenSquareRoot(t *testing.T) {
+	window := newGoldenWindow(t)
+	window.Frame()
+	drawMathText(window, "sqrt 49 = 7", 50, 50, 6, draw.White)
+	drawMathText(window, "2 + sqrt 144 = ", 50, 250, 2, draw.Yellow)
+	drawMathText(window, "sqrt 81", 50, 350, 1.5, draw.White)
+	checkGolden(t, window, "square root")
+}
+
+// newGoldenWindow resets the game to a known state and returns an offscreen
+// window to draw it in. Random numbers, time and storage are the same for
+// every test.
+func newGoldenWindow(t *testing.T) *offscreen.Window {
+	isolateStorage(t)
+	draw.OpenFile = func(path string) (io.ReadCloser, error) {
+		return rsc.Open("rsc/" + path)
+	}
+	rand.Seed(1)
+	goldenTime = time.Date(2018, 4, 21, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time {
+		return goldenTime
+	}
+	t.Cleanup(func() {
+		timeNow = time.Now
+	})
+	currentProfile = defaultProfile
+	currentPack = packChoice{}
+	*menu = menuState{hotItem: menuStart, difficulty: mathgen.Normal}
+	*playing = playingState{}
+	*dead = deadState{}
+	return offscreen.New(windowW, windowH)
+}
+
+// goldenTime is the time in the golden tests, each frame takes one game.Tick.
+var goldenTime time.Time
+
+// run updates the state for the given number of frames like main does and
+// returns the state after the last frame. Only the last frame is left in the
+// window. If input is not nil, it is called before each frame to press keys.
+func run(window *offscreen.Window, s state, frameCount int, input func(frame int)) state {
+	for frame := 0; frame < frameCount; frame++ {
+		window.Frame()
+		goldenTime = goldenTime.Add(game.Tick)
+		if input != nil {
+			input(frame)
+		}
+		newState := s.update(window)
+		if s != newState {
+			s.leave()
+			newState.enter(s)
+		}
+		s = newState
+	}
+	return s
+}
+
+func startGame(seed int64) {
+	playing.settings = gameSettings{
+		players:    1,
+		seed:       seed,
+		difficulty: mathgen.Normal,
+	}
+	playing.enter(menu)
+}
+
+// playScripted plays 4.5 seconds in which the player shoots every second and
+// takes a step to the left in between.
+func playScripted(t *testing.T, window *offscreen.Window) {
+	s := run(window, playing, frames(4500*time.Millisecond), func(frame int) {
+		window.Release(draw.KeyLeft)
+		switch frame % 60 {
+		case 30:
+			pressAnswer(window)
+		case 40:
+			window.Press(draw.KeyLeft)
+		}
+	})
+	if s != playing {
+		t.Fatal("the game ended early")
+	}
+}
+
+// pressAnswer types the answer to the player's shot problem.
+func pressAnswer(window *offscreen.Window) {
+	answer := playing.world.Players[0].Assignment.Answer.Int()
+	window.Press(allKeys.digits[answer][0])
+}
+
+// waitForDeath plays without drawing until the zombies got the idle hero and
+// they are bleeding.
+func waitForDeath(t *testing.T) {
+	for i := 0; i < frames(time.Minute); i++ {
+		if playing.world.Players[0].Torso == game.Bleeding {
+			return
+		}
+		if playing.step() != playing {
+			break
+		}
+	}
+	t.Fatal("the hero did not die")
+}
+
+// checkGolden compares the window's image with the golden frame of that name.
+func checkGolden(t *testing.T, window *offscreen.Window, name string) {
+	t.Helper()
+	got := window.Image()
+	goldenPath := filepath.Join(goldenFolder, name+".png")
+	if *updateGolden {
+		if err := writePNG(goldenPath, got); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := readPNG(goldenPath)
+	if err != nil {
+		t.Fatalf("%v, run the test with -update to create the golden frame", err)
+	}
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("the frame is %v but the golden frame is %v", got.Bounds(), want.Bounds())
+	}
+	diff, different := compareImages(got, want)
+	if float64(different) <= pixelTolerance*float64(got.Rect.Dx()*got.Rect.Dy()) {
+		return
+	}
+	gotPath := filepath.Join(failedFolder, name+".png")
+	diffPath := filepath.Join(failedFolder, name+" diff.png")
+	if err := writePNG(gotPath, got); err != nil {
+		t.Error(err)
+	}
+	if err := writePNG(diffPath, diff); err != nil {
+		t.Error(err)
+	}
+	t.Errorf("%d pixels differ from %s, see %s and %s", different, goldenPath, gotPath, diffPath)
+}
+
+// compareImages counts the pixels that differ and returns an image of the
+// differences. It shows the golden frame darkened, with the differing pixels in
+// red.
+func compareImages(got *image.RGBA, want image.Image) (*image.RGBA, int) {
+	diff := image.NewRGBA(got.Bounds())
+	different := 0
+	b := got.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			g := got.RGBAAt(x, y)
+			w := color.RGBAModel.Convert(want.At(x, y)).(color.RGBA)
+			if similar(g.R, w.R) && similar(g.G, w.G) && similar(g.B, w.B) {
+				diff.SetRGBA(x, y, color.RGBA{w.R / 4, w.G / 4, w.B / 4, 255})
+			} else {
+				different++
+				diff.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+			}
+		}
+	}
+	return diff, different
+}
+
+func similar(a, b uint8) bool {
+	if a > b {
+		a, b = b, a
+	}
+	return b-a <= channelTolerance
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, img)
+}
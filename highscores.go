@@ -6,6 +6,13 @@ import (
 	"strings"
 )
 
+const highscoresFile = "brainless_jogging_highscores"
+
+// mainTable is the high score table of the regular game. Other game modes have
+// their own tables. Scores used to be the number of killed zombies, those are
+// left in the old file so they do not mix with points.
+const mainTable = "_points"
+
 type highscore struct {
 	score int
 	name  string
@@ -18,6 +25,23 @@ func (x byScore) Len() int           { return len(x) }
 func (x byScore) Less(i, j int) bool { return x[i].score > x[j].score }
 func (x byScore) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
 
+func loadHighScores(table string) []highscore {
+	if date, ok := dailyTableDate(table); ok {
+		return loadDailyScores()[date]
+	}
+	return parseHighscores(loadText(highscoresFile + table))
+}
+
+func saveHighScores(table string, scores []highscore) {
+	if date, ok := dailyTableDate(table); ok {
+		daily := loadDailyScores()
+		daily[date] = scores
+		saveDailyScores(daily)
+		return
+	}
+	saveText(highscoresFile+table, highscoresToString(scores))
+}
+
 func parseHighscores(text string) []highscore {
 	lines := strings.Split(text, "\n")
 	var scores []highscore
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gonutz/prototype/draw"
+)
+
+const historyDays = 10
+
+// historyState lists the best result of each of the last daily challenges.
+type historyState struct {
+	days []dailyResult
+}
+
+type dailyResult struct {
+	date string
+	best highscore
+}
+
+func (s *historyState) enter(state) {
+	s.days = nil
+	for date, scores := range loadDailyScores() {
+		if len(scores) > 0 {
+			sort.Stable(byScore(scores))
+			s.days = append(s.days, dailyResult{date: date, best: scores[0]})
+		}
+	}
+	sort.Slice(s.days, func(i, j int) bool {
+		return s.days[i].date > s.days[j].date
+	})
+	if len(s.days) > historyDays {
+		s.days = s.days[:historyDays]
+	}
+}
+
+func (*historyState) leave() {}
+
+func (s *historyState) update(window draw.Window) state {
+	if window.WasKeyPressed(draw.KeyEscape) ||
+		window.WasKeyPressed(draw.KeyEnter) ||
+		window.WasKeyPressed(draw.KeyNumEnter) {
+		return menu
+	}
+	const (
+		title      = "Daily Challenge History"
+		titleScale = 3
+		lineScale  = 2
+	)
+	w, h := window.GetScaledTextSize(title, titleScale)
+	window.DrawScaledText(title, (windowW-w)/2, 50, titleScale, draw.White)
+	lines := []string{"No daily challenge played yet."}
+	if len(s.days) > 0 {
+		lines = nil
+		for _, day := range s.days {
+			text := fmt.Sprintf("%s %6d  %s", day.date, day.best.score, day.best.name)
+			if day.date == today() {
+				text += " (today)"
+			}
+			lines = append(lines, text)
+		}
+	}
+	lineW, lineH := window.GetScaledTextSize(
+		fmt.Sprintf("%s %6d  %*s (today)", today(), 0, maxNameLen, ""),
+		lineScale,
+	)
+	for i, line := range lines {
+		window.DrawScaledText(line, (windowW-lineW)/2, 50+h+50+i*lineH, lineScale, draw.White)
+	}
+	return history
+}
@@ -16,24 +16,27 @@ func (*instructionsState) update(window draw.Window) state {
 	}
 	const (
 		text = `
-   Solve math problems.
-      Shoot zombies.
-         Survive!
+               Solve math problems.
+                  Shoot zombies.
+                     Survive!
 
-  Enter the solution to
-the calculation above your
-  head to shoot your gun.
+    Enter the solution to the calculation above
+       your head to shoot your gun. Failing
+             delays your next shot.
 
- Failing delays your next
-          shot.
+After six shots, solve a harder problem to reload.
 
- Use the Left/Right arrow 
-   keys or A/D to move.
+ Zombies drop power-ups, walk over them to pick
+      them up. SPACE uses a free answer.
 
+ Use the Left/Right arrow keys or A/D to move.
 
-   Press ENTER to play
+ Co-op: P1 uses 0-9, A/D and SPACE, P2 uses the
+  numpad, the arrow keys and the numpad ENTER.
+
+               Press ENTER to play
 `
-		scale = 2
+		scale = 1.8
 	)
 	w, h := window.GetScaledTextSize(text, scale)
 	window.DrawScaledText(text, (windowW-w)/2, (windowH-h)/2, scale, draw.White)
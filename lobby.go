@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gonutz/prototype/draw"
+)
+
+const maxAddressLen = 40
+
+// lobbyState is where two players on the same network meet for a LAN race. One
+// of them hosts the game, the other one joins by typing the host's address.
+type lobbyState struct {
+	hotItem  int
+	frame    int
+	address  string
+	message  string // status or the result of the last match
+	listener net.Listener
+	started  chan versusStart // receives the result of hosting or joining
+}
+
+type versusStart struct {
+	conn *versusConn
+	seed int64
+	err  error
+}
+
+const (
+	lobbyHost = iota
+	lobbyJoin
+	lobbyBack
+	lobbyItemCount
+)
+
+func (s *lobbyState) enter(from state) {
+	if s.address == "" {
+		s.address = "localhost"
+	}
+	s.message = ""
+	if from == playing {
+		s.message = playing.versusOver
+	}
+	s.listener = nil
+	s.started = nil
+}
+
+func (s *lobbyState) leave() {
+	s.cancel()
+}
+
+// cancel stops hosting or joining. A connection that is made after this is
+// closed right away.
+func (s *lobbyState) cancel() {
+	if s.listener != nil {
+		s.listener.Close()
+		s.listener = nil
+	}
+	if s.started != nil {
+		go func(started chan versusStart) {
+			if start := <-started; start.conn != nil {
+				start.conn.close()
+			}
+		}(s.started)
+		s.started = nil
+	}
+}
+
+func (s *lobbyState) waiting() bool {
+	return s.started != nil
+}
+
+func (s *lobbyState) update(window draw.Window) state {
+	if window.WasKeyPressed(draw.KeyEscape) {
+		if s.waiting() {
+			s.cancel()
+			s.message = ""
+		} else {
+			return menu
+		}
+	}
+	if s.waiting() {
+		select {
+		case start := <-s.started:
+			s.started = nil
+			s.cancel()
+			if start.err != nil {
+				s.message = start.err.Error()
+			} else {
+				playing.settings = gameSettings{
+					players: 1,
+					seed:    start.seed,
+					versus:  start.conn,
+				}
+				return playing
+			}
+		default:
+		}
+	} else {
+		oldItem := s.hotItem
+		if window.WasKeyPressed(draw.KeyDown) {
+			s.hotItem = (s.hotItem + 1) % lobbyItemCount
+		}
+		if window.WasKeyPressed(draw.KeyUp) {
+			s.hotItem = (s.hotItem + lobbyItemCount - 1) % lobbyItemCount
+		}
+		if s.hotItem != oldItem {
+			window.PlaySoundFile("menu beep.wav")
+		}
+		if s.hotItem == lobbyJoin {
+			for _, r := range window.Characters() {
+				if len(s.address) < maxAddressLen && strings.ContainsRune(
+					"abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789.:-[]", r) {
+					s.address += string(r)
+				}
+			}
+			if window.WasKeyPressed(draw.KeyBackspace) && s.address != "" {
+				s.address = s.address[:len(s.address)-1]
+			}
+		}
+		if window.WasKeyPressed(draw.KeyEnter) || window.WasKeyPressed(draw.KeyNumEnter) {
+			switch s.hotItem {
+			case lobbyHost:
+				s.host()
+			case lobbyJoin:
+				s.join()
+			case lobbyBack:
+				return menu
+			}
+		}
+	}
+	// render
+	const textScale = 3
+	items := []string{
+		"Host Game",
+		"Join " + s.address,
+		"Back",
+	}
+	s.frame++
+	cursorVisible := (s.frame/frames(cursorBlinkTime))%2 == 0
+	if s.hotItem == lobbyJoin && !s.waiting() && cursorVisible {
+		items[lobbyJoin] += "|"
+	}
+	_, lineH := window.GetScaledTextSize("A", textScale)
+	for i, item := range items {
+		w, _ := window.GetScaledTextSize(item, textScale)
+		x := (windowW - w) / 2
+		y := (windowH-lineH*len(items))/2 + i*lineH
+		if i == s.hotItem {
+			color := draw.DarkRed
+			if s.waiting() {
+				color = draw.DarkGray
+			}
+			window.FillRect(x-20, y, w+40, lineH, color)
+		}
+		window.DrawScaledText(item, x, y, textScale, draw.White)
+	}
+	title := "LAN Race"
+	w, _ := window.GetScaledTextSize(title, textScale)
+	window.DrawScaledText(title, (windowW-w)/2, 50, textScale, draw.DarkRed)
+	const messageScale = 2
+	w, _ = window.GetScaledTextSize(s.message, messageScale)
+	window.DrawScaledText(s.message, (windowW-w)/2, windowH-150, messageScale, draw.White)
+
+	return lobby
+}
+
+func (s *lobbyState) host() {
+	l, err := net.Listen("tcp", ":"+strconv.Itoa(versusPort))
+	if err != nil {
+		s.message = err.Error()
+		return
+	}
+	s.listener = l
+	s.message = fmt.Sprintf("Waiting for an opponent on port %d", versusPort)
+	if addrs := localAddresses(); len(addrs) > 0 {
+		s.message += "\nYour address: " + strings.Join(addrs, ", ")
+	}
+	started := make(chan versusStart, 1)
+	s.started = started
+	seed := time.Now().UnixNano()
+	go func() {
+		conn, err := hostVersus(l, seed)
+		started <- versusStart{conn: conn, seed: seed, err: err}
+	}()
+}
+
+func (s *lobbyState) join() {
+	s.message = "Joining " + s.address + " ..."
+	started := make(chan versusStart, 1)
+	s.started = started
+	address := s.address
+	go func() {
+		conn, seed, err := joinVersus(address)
+		started <- versusStart{conn: conn, seed: seed, err: err}
+	}()
+}
@@ -30,6 +30,12 @@ var (
 	playing      = &playingState{}
 	dead         = &deadState{}
 	instructions = &instructionsState{}
+	lobby        = &lobbyState{}
+	history      = &historyState{}
+	practice     = &practiceState{}
+	profile      = &profileState{}
+	packs        = &packsState{}
+	achievements = &achievementsState{}
 )
 
 func main() {
@@ -38,27 +44,41 @@ func main() {
 	}
 
 	rand.Seed(time.Now().UnixNano())
+	currentProfile = loadProfile()
 
 	var state state = menu
 	state.enter(nil)
 
 	var musicStart time.Time
+	var capture screenCapture
 	firstFrame := true
+	cursorShown := false
 
 	check(draw.RunWindow(windowTitle, windowW, windowH, func(window draw.Window) {
 		if firstFrame {
 			window.SetIcon("icon.png")
 			window.ShowCursor(false)
+			// images are scaled to the window size, see screen
+			window.BlurImages(true)
 			preloadAssets(window)
 			firstFrame = false
 		}
 
-		newState := state.update(window)
+		fitCanvas()
+		toggleFullscreen(window)
+		view := fitScreen(window)
+		newState := state.update(capture.window(view))
 		if state != newState {
 			state.leave()
 			newState.enter(state)
 		}
 		state = newState
+		if show := state == playing && playing.showsCursor(); show != cursorShown {
+			window.ShowCursor(show)
+			cursorShown = show
+		}
+		capture.finish(view)
+		view.drawBars()
 
 		now := time.Now()
 		if now.Sub(musicStart) >= musicLength {
@@ -0,0 +1,63 @@
+package mathgen
+
+import "fmt"
+
+// Choices returns n answers for a multiple choice question, one of which is
+// the answer to a. The others are mistakes that are easy to make: the result of
+// the wrong operation on the same numbers, like 3 * 4 for 3 + 4, or an answer
+// that is off by one or two. None of the choices is negative and they are in
+// random order.
+func Choices(a Assignment, n int, rand func() int) []Number {
+	var candidates []Number
+	consider := func(x Number) {
+		if x.Less(Whole(0)) || x.Equal(a.Answer) {
+			return
+		}
+		for _, c := range candidates {
+			if c.Equal(x) {
+				return
+			}
+		}
+		candidates = append(candidates, x)
+	}
+	var x, y int
+	var op string
+	if k, _ := fmt.Sscanf(a.Question, "%d %s %d", &x, &op, &y); k == 3 {
+		switch op {
+		case "+", "-", "*", "/", "mod":
+			consider(Whole(x + y))
+			consider(Whole(x - y))
+			consider(Whole(x * y))
+			if y != 0 {
+				consider(Whole(x / y))
+				consider(Whole(x % y))
+			}
+		}
+	}
+	for _, d := range []int{1, -1, 2, -2} {
+		consider(a.Answer.plus(d))
+	}
+	// pick the wrong answers at random, if there are not enough, the ones
+	// that are further off are used as well
+	var choices []Number
+	for len(choices) < n-1 && len(candidates) > 0 {
+		i := rand() % len(candidates)
+		choices = append(choices, candidates[i])
+		candidates = append(candidates[:i], candidates[i+1:]...)
+	}
+	for d := 3; len(choices) < n-1; d++ {
+		choices = append(choices, a.Answer.plus(d))
+	}
+	i := rand() % n
+	choices = append(choices[:i], append([]Number{a.Answer}, choices[i:]...)...)
+	return choices
+}
+
+// plus returns n+d, decimals stay decimals.
+func (n Number) plus(d int) Number {
+	m := Fraction(n.Num+d*n.Den, n.Den)
+	if n.Decimal {
+		m = m.AsDecimal()
+	}
+	return m
+}
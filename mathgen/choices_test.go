@@ -0,0 +1,65 @@
+package mathgen
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestChoicesContainTheAnswerOnce(t *testing.T) {
+	r := rand.New(rand.NewSource(1)).Int
+	for _, d := range Difficulties {
+		for _, g := range []Generator{d.Shots(), d.Reloads()} {
+			for _, op := range g.Ops {
+				for _, a := range g.Problems(op) {
+					for _, n := range []int{3, 4} {
+						checkChoices(t, a, Choices(a, n, r), n)
+					}
+				}
+			}
+		}
+	}
+}
+
+func checkChoices(t *testing.T, a Assignment, choices []Number, n int) {
+	t.Helper()
+	if len(choices) != n {
+		t.Fatalf("%s: %d choices, want %d", a.Question, len(choices), n)
+	}
+	answers := 0
+	for i, c := range choices {
+		if c.Equal(a.Answer) {
+			answers++
+		}
+		if c.Less(Whole(0)) {
+			t.Errorf("%s: choice %s is negative", a.Question, c)
+		}
+		for _, other := range choices[:i] {
+			if c.Equal(other) {
+				t.Errorf("%s: choice %s comes twice", a.Question, c)
+			}
+		}
+	}
+	if answers != 1 {
+		t.Errorf("%s: the answer %s is among the choices %d times", a.Question, a.Answer, answers)
+	}
+}
+
+func TestChoicesUseTheWrongOperation(t *testing.T) {
+	a := Assignment{Question: "3 + 4", Answer: Whole(7), Op: Add}
+	r := rand.New(rand.NewSource(1)).Int
+	seen := make(map[Number]bool)
+	for i := 0; i < 1000; i++ {
+		for _, c := range Choices(a, 4, r) {
+			seen[c] = true
+		}
+	}
+	// 3 * 4, off by one and off by two
+	for _, want := range []int{12, 6, 8, 5, 9} {
+		if !seen[Whole(want)] {
+			t.Errorf("%d is never a choice for 3 + 4", want)
+		}
+	}
+	if seen[Whole(-1)] {
+		t.Error("3 - 4 must not be a choice")
+	}
+}
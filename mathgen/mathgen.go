@@ -0,0 +1,459 @@
+// Package mathgen creates the math problems for the game. It is also used by
+// the tools in the cmd folder so they produce the same problems as the game.
+package mathgen
+
+import (
+	"fmt"
+	"sync"
+)
+
+const (
+	// TimesTableSize is the largest number that a times table goes up to.
+	TimesTableSize = 10
+	// MaxDenominator is the largest common denominator of two fractions.
+	MaxDenominator = 12
+	// MaxPlaceValue is the largest number that PlaceValue asks about.
+	MaxPlaceValue = 9999
+)
+
+// Generator creates random assignments with the operations in Ops. An operation
+// that appears more than once in Ops is picked more often.
+//
+// For the chosen operation, the assignment is one of these valid problems:
+//
+//	a + b = c    with 0 <= a, b and c <= Max
+//	a - b = c    with 0 <= b, c and a <= Max
+//	a * b = c    with 0 <= a, b and c <= Max
+//	a / b = c    with 1 <= b, c and a <= Max
+//	a ^ b = c    with 2 <= b, c <= Max and b = 2 for a < 2
+//	sqrt a = c   with a = c * c and c <= Max
+//	a mod b = c  with 0 <= a <= Max and 2 <= b <= Max
+//	a/b + c/d    with 0 < a < b, 0 < c < d, their common denominator up to
+//	             MaxDenominator and the result up to Max, or a/b - c/d >= 0
+//	0.a * b      with 1 <= a <= 9, 1 <= b <= TimesTableSize and a result up
+//	             to Max
+//	c base 2     with c written in binary and 0 <= c <= Max
+//	c base 16    with c written in hexadecimal and 0 <= c <= Max
+//	tens digit of a  with 10 <= a <= MaxPlaceValue, asking for any of its
+//	             digits, and the digit up to Max
+//	XIV          with the Roman numeral of c and 1 <= c <= Max
+//
+// If Factor is set, Multiply and Divide use the times table instead:
+//
+//	a * b = c    with Factor being a or b and the other one up to TimesTableSize
+//	a / b = c    with b = Factor and 1 <= c <= TimesTableSize
+//
+// Weighting decides how likely each of these problems is.
+type Generator struct {
+	Ops []Op
+	Max int
+	// Factor, if not 0, makes Multiply and Divide use the times table of this
+	// number, e.g. 7 * 3 or 21 / 7, instead of Max.
+	Factor int
+	// WholeAnswers leaves out Decimals with answers like 1.5 and makes
+	// Fractions ask for the numerator, e.g. 1/2 + 1/4 = ?/4.
+	WholeAnswers bool
+	Weighting    Weighting
+	// Review, if not nil, provides assignments that the player should practice
+	// again. They make up ReviewShare percent of the generated assignments.
+	Review      Reviewer
+	ReviewShare int
+}
+
+type Weighting int
+
+const (
+	// ByAnswer makes every possible answer equally likely. Of the problems with
+	// the chosen answer, each one is equally likely. This way 0 does not come
+	// up more often than other answers in a * b, even though many more
+	// problems have 0 as their answer.
+	ByAnswer Weighting = iota
+	// ByProblem makes every valid problem equally likely. Answers that can be
+	// reached in more ways come up more often, e.g. 9 in a + b.
+	ByProblem
+)
+
+// Reviewer provides the assignments that are due for review. It only returns
+// assignments that g could have generated itself.
+type Reviewer interface {
+	Due(g Generator) []Assignment
+}
+
+// Difficulty is a preset for the generators that the game uses. The zero
+// value is Normal.
+type Difficulty int
+
+const (
+	Normal Difficulty = iota
+	Easy
+	Hard
+	// Numerals asks to read binary, hexadecimal and Roman numbers and the
+	// digits of large numbers instead of calculating.
+	Numerals
+)
+
+// Difficulties lists all presets from easy to hard, followed by the special
+// ones.
+var Difficulties = []Difficulty{Easy, Normal, Hard, Numerals}
+
+func (d Difficulty) String() string {
+	switch d {
+	case Easy:
+		return "Easy"
+	case Normal:
+		return "Normal"
+	case Hard:
+		return "Hard"
+	case Numerals:
+		return "Numerals"
+	default:
+		panic("invalid Difficulty")
+	}
+}
+
+// Shots returns the generator for the problems that are solved to shoot. Their
+// answers are single digits.
+func (d Difficulty) Shots() Generator {
+	g := Generator{Max: 9, WholeAnswers: true}
+	switch d {
+	case Easy:
+		g.Ops = []Op{Add, Subtract}
+	case Hard:
+		g.Ops = []Op{Add, Subtract, Multiply, Divide, Power, SquareRoot, Modulo, Fractions, Decimals}
+	case Numerals:
+		// hexadecimal numbers below 10 look like decimal ones
+		g.Ops = []Op{Binary, PlaceValue, Roman}
+	default:
+		g.Ops = []Op{Add, Subtract, Add, Subtract, Multiply, Divide}
+	}
+	return g
+}
+
+// Reloads returns the generator for the harder reload problems. Their answers
+// may have more than one digit.
+func (d Difficulty) Reloads() Generator {
+	switch d {
+	case Easy:
+		return Generator{Ops: []Op{Add, Subtract}, Max: 20}
+	case Hard:
+		return Generator{
+			Ops: []Op{Add, Subtract, Multiply, Divide, Power, SquareRoot, Modulo, Fractions, Decimals},
+			Max: 30,
+		}
+	case Numerals:
+		// up to 11111 in binary and 1F in hexadecimal
+		return Generator{Ops: []Op{Binary, Hexadecimal, PlaceValue, Roman}, Max: 31}
+	default:
+		return Generator{Ops: []Op{Add, Subtract, Multiply, Divide}, Max: 20}
+	}
+}
+
+type Op int
+
+const (
+	Add Op = iota
+	Subtract
+	Multiply
+	Divide
+	Power
+	SquareRoot
+	Modulo
+	Fractions
+	Decimals
+	Binary
+	Hexadecimal
+	PlaceValue
+	Roman
+	// Custom is not an operation, it marks assignments that were not
+	// generated, e.g. the ones from a problem pack.
+	Custom
+	OpCount
+)
+
+func (op Op) String() string {
+	switch op {
+	case Add:
+		return "+"
+	case Subtract:
+		return "-"
+	case Multiply:
+		return "*"
+	case Divide:
+		return "/"
+	case Power:
+		return "^"
+	case SquareRoot:
+		return "sqrt"
+	case Modulo:
+		return "mod"
+	case Fractions:
+		return "frac"
+	case Decimals:
+		return "dec"
+	case Binary:
+		return "bin"
+	case Hexadecimal:
+		return "hex"
+	case PlaceValue:
+		return "place"
+	case Roman:
+		return "roman"
+	case Custom:
+		return "?"
+	default:
+		panic("invalid Op")
+	}
+}
+
+type Assignment struct {
+	Question string
+	Answer   Number
+	Op       Op
+}
+
+// Generate picks one of the Ops and returns a random valid problem for it, see
+// Generator for the problems of each Op. If Review is set, a due assignment is
+// returned instead for ReviewShare percent of the calls.
+func (g Generator) Generate(rand func() int) Assignment {
+	if g.Review != nil && rand()%100 < g.ReviewShare {
+		if due := g.Review.Due(g); len(due) > 0 {
+			return due[rand()%len(due)]
+		}
+	}
+	op := g.Ops[rand()%len(g.Ops)]
+	set := g.problemSet(op)
+	problems := set.all
+	if g.Weighting == ByAnswer {
+		answer := set.answers[rand()%len(set.answers)]
+		problems = set.byAnswer[answer]
+	}
+	return problems[rand()%len(problems)].assignment(op)
+}
+
+// Problems returns all valid problems for op, see Generator.
+func (g Generator) Problems(op Op) []Assignment {
+	var list []Assignment
+	for _, p := range g.problemSet(op).all {
+		list = append(list, p.assignment(op))
+	}
+	return list
+}
+
+// CanGenerate reports whether a could have been created by this generator.
+func (g Generator) CanGenerate(a Assignment) bool {
+	for _, op := range g.Ops {
+		if op == a.Op {
+			for _, p := range g.problemSet(op).byAnswer[a.Answer] {
+				if p.assignment(op) == a {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return false
+}
+
+// problemSet holds the valid problems for one operation and range. Listing them
+// takes a while, so each set is only created once.
+type problemSet struct {
+	all      []operands
+	answers  []Number // in the order that they first appear in all
+	byAnswer map[Number][]operands
+}
+
+type problemKey struct {
+	op          Op
+	max, factor int
+	whole       bool
+}
+
+var (
+	problemSetsMu sync.Mutex
+	problemSets   = make(map[problemKey]*problemSet)
+)
+
+func (g Generator) problemSet(op Op) *problemSet {
+	key := problemKey{op: op, max: g.Max, factor: g.Factor, whole: g.WholeAnswers}
+	problemSetsMu.Lock()
+	defer problemSetsMu.Unlock()
+	if set, ok := problemSets[key]; ok {
+		return set
+	}
+	set := &problemSet{
+		all:      g.operands(op),
+		byAnswer: make(map[Number][]operands),
+	}
+	for _, p := range set.all {
+		if _, ok := set.byAnswer[p.answer]; !ok {
+			set.answers = append(set.answers, p.answer)
+		}
+		set.byAnswer[p.answer] = append(set.byAnswer[p.answer], p)
+	}
+	problemSets[key] = set
+	return set
+}
+
+// placeNames are the names of the digits in a PlaceValue question, from the
+// ones digit to the thousands digit.
+var placeNames = []string{"ones", "tens", "hundreds", "thousands"}
+
+type operands struct {
+	a, b   int
+	c, d   int  // the second fraction in a/b + c/d
+	minus  bool // a/b - c/d instead of a/b + c/d
+	ask    int  // if not 0, the question asks for the numerator over ask
+	answer Number
+}
+
+func (p operands) assignment(op Op) Assignment {
+	question := fmt.Sprintf("%d %s %d", p.a, op, p.b)
+	switch op {
+	case Power:
+		question = fmt.Sprintf("%d%s%d", p.a, op, p.b)
+	case SquareRoot:
+		question = fmt.Sprintf("%s %d", op, p.a)
+	case Fractions:
+		sign := "+"
+		if p.minus {
+			sign = "-"
+		}
+		question = fmt.Sprintf("%d/%d %s %d/%d", p.a, p.b, sign, p.c, p.d)
+		if p.ask != 0 {
+			question += fmt.Sprintf(" = ?/%d", p.ask)
+		}
+	case Decimals:
+		question = fmt.Sprintf("0.%d * %d", p.a, p.b)
+	case Binary:
+		question = fmt.Sprintf("%b base 2", p.a)
+	case Hexadecimal:
+		question = fmt.Sprintf("%X base 16", p.a)
+	case PlaceValue:
+		question = fmt.Sprintf("%s digit of %d", placeNames[p.b], p.a)
+	case Roman:
+		question = RomanNumeral(p.a)
+	}
+	return Assignment{
+		Question: question,
+		Answer:   p.answer,
+		Op:       op,
+	}
+}
+
+// operands lists the valid problems for op, in a fixed order.
+func (g Generator) operands(op Op) []operands {
+	var list []operands
+	add := func(a, b, answer int) {
+		list = append(list, operands{a: a, b: b, answer: Whole(answer)})
+	}
+	switch op {
+	case Add:
+		for c := 0; c <= g.Max; c++ {
+			for a := 0; a <= c; a++ {
+				add(a, c-a, c)
+			}
+		}
+	case Subtract:
+		for a := 0; a <= g.Max; a++ {
+			for b := 0; b <= a; b++ {
+				add(a, b, a-b)
+			}
+		}
+	case Multiply:
+		if g.Factor != 0 {
+			for b := 0; b <= TimesTableSize; b++ {
+				add(g.Factor, b, g.Factor*b)
+				if b != g.Factor {
+					add(b, g.Factor, g.Factor*b)
+				}
+			}
+			break
+		}
+		for a := 0; a <= g.Max; a++ {
+			for b := 0; b <= g.Max && a*b <= g.Max; b++ {
+				add(a, b, a*b)
+			}
+		}
+	case Divide:
+		if g.Factor != 0 {
+			for c := 1; c <= TimesTableSize; c++ {
+				add(c*g.Factor, g.Factor, c)
+			}
+			break
+		}
+		for b := 1; b <= g.Max; b++ {
+			for c := 1; b*c <= g.Max; c++ {
+				add(b*c, b, c)
+			}
+		}
+	case Power:
+		for a := 0; a*a <= g.Max; a++ {
+			add(a, 2, a*a)
+			for b, c := 3, a*a*a; a >= 2 && c <= g.Max; b, c = b+1, c*a {
+				add(a, b, c)
+			}
+		}
+	case SquareRoot:
+		for c := 0; c <= g.Max; c++ {
+			add(c*c, 0, c)
+		}
+	case Modulo:
+		for a := 0; a <= g.Max; a++ {
+			for b := 2; b <= g.Max; b++ {
+				add(a, b, a%b)
+			}
+		}
+	case Fractions:
+		for b := 2; b <= MaxDenominator; b++ {
+			for d := 2; d <= MaxDenominator; d++ {
+				lcm := b * d / gcd(b, d)
+				if lcm > MaxDenominator {
+					continue
+				}
+				for a := 1; a < b; a++ {
+					for c := 1; c < d; c++ {
+						for _, minus := range []bool{false, true} {
+							num := a*(lcm/b) + c*(lcm/d)
+							if minus {
+								num = a*(lcm/b) - c*(lcm/d)
+							}
+							p := operands{a: a, b: b, c: c, d: d, minus: minus, answer: Fraction(num, lcm)}
+							if g.WholeAnswers {
+								p.ask, p.answer = lcm, Whole(num)
+							}
+							if num >= 0 && !Whole(g.Max).Less(p.answer) {
+								list = append(list, p)
+							}
+						}
+					}
+				}
+			}
+		}
+	case Decimals:
+		for tenths := 1; tenths <= 9; tenths++ {
+			for b := 1; b <= TimesTableSize; b++ {
+				answer := Fraction(tenths*b, 10).AsDecimal()
+				if (answer.IsWhole() || !g.WholeAnswers) && !Whole(g.Max).Less(answer) {
+					list = append(list, operands{a: tenths, b: b, answer: answer})
+				}
+			}
+		}
+	case Binary, Hexadecimal:
+		for c := 0; c <= g.Max; c++ {
+			add(c, 0, c)
+		}
+	case PlaceValue:
+		for a := 10; a <= MaxPlaceValue; a++ {
+			for place, unit := 0, 1; unit <= a; place, unit = place+1, unit*10 {
+				if digit := a / unit % 10; digit <= g.Max {
+					add(a, place, digit)
+				}
+			}
+		}
+	case Roman:
+		for c := 1; c <= g.Max; c++ {
+			add(c, 0, c)
+		}
+	}
+	return list
+}
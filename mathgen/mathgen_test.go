@@ -0,0 +1,319 @@
+package mathgen
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Run the tests with -v to see the histograms.
+
+var generatedOps = []Op{Add, Subtract, Multiply, Divide, Power, SquareRoot, Modulo, Fractions, Decimals,
+	Binary, Hexadecimal, PlaceValue, Roman}
+
+// generators are the settings that the game and its tools use.
+func generators() map[string]Generator {
+	gens := make(map[string]Generator)
+	for _, d := range Difficulties {
+		gens[d.String()+" shots"] = d.Shots()
+		gens[d.String()+" reloads"] = d.Reloads()
+	}
+	for _, op := range generatedOps {
+		for _, max := range []int{2, 9, 20} {
+			gens[fmt.Sprintf("%s max %d", op, max)] = Generator{Ops: []Op{op}, Max: max}
+			gens[fmt.Sprintf("%s max %d whole", op, max)] = Generator{Ops: []Op{op}, Max: max, WholeAnswers: true}
+		}
+	}
+	for factor := 1; factor <= TimesTableSize; factor++ {
+		gens[fmt.Sprintf("* table %d", factor)] = Generator{Ops: []Op{Multiply}, Factor: factor}
+		gens[fmt.Sprintf("/ table %d", factor)] = Generator{Ops: []Op{Divide}, Factor: factor}
+	}
+	for name, g := range gens {
+		g.Weighting = ByProblem
+		gens[name+" by problem"] = g
+	}
+	return gens
+}
+
+func TestProblemsAreCorrectAndInRange(t *testing.T) {
+	for name, g := range generators() {
+		for _, op := range g.Ops {
+			problems := g.Problems(op)
+			if len(problems) == 0 {
+				t.Errorf("%s: no problems for %s", name, op)
+			}
+			seen := make(map[Assignment]bool)
+			for _, p := range problems {
+				if seen[p] {
+					t.Errorf("%s: %s is listed twice", name, p.Question)
+				}
+				seen[p] = true
+				if p.Op != op {
+					t.Errorf("%s: %s has op %s, want %s", name, p.Question, p.Op, op)
+				}
+			}
+			for _, p := range g.operands(op) {
+				checkProblem(t, name, g, op, p)
+			}
+		}
+	}
+}
+
+func checkProblem(t *testing.T, name string, g Generator, op Op, p operands) {
+	t.Helper()
+	question := p.assignment(op).Question
+	want := Whole(-1)
+	limited := p.answer // must not be larger than g.Max
+	switch op {
+	case Add:
+		want = Whole(p.a + p.b)
+	case Subtract:
+		want = Whole(p.a - p.b)
+		limited = Whole(p.a)
+	case Multiply:
+		want = Whole(p.a * p.b)
+	case Divide:
+		if p.b != 0 && p.a%p.b == 0 {
+			want = Whole(p.a / p.b)
+		}
+		limited = Whole(p.a)
+	case Power:
+		if p.b < 2 || p.a < 2 && p.b != 2 {
+			t.Errorf("%s: %s has a trivial exponent", name, question)
+		}
+		n := 1
+		for i := 0; i < p.b; i++ {
+			n *= p.a
+		}
+		want = Whole(n)
+	case SquareRoot:
+		if n := p.answer.Int(); n*n == p.a {
+			want = Whole(n)
+		}
+	case Modulo:
+		if p.b < 2 {
+			t.Errorf("%s: %s has a trivial divisor", name, question)
+		} else {
+			want = Whole(p.a % p.b)
+		}
+		limited = Whole(p.a)
+	case Fractions:
+		if p.a < 1 || p.a >= p.b || p.c < 1 || p.c >= p.d {
+			t.Errorf("%s: %s has improper fractions", name, question)
+		}
+		lcm := p.b * p.d / gcd(p.b, p.d)
+		if lcm > MaxDenominator {
+			t.Errorf("%s: %s has a common denominator above %d", name, question, MaxDenominator)
+		}
+		want = Fraction(p.a*p.d+p.c*p.b, p.b*p.d)
+		if p.minus {
+			want = Fraction(p.a*p.d-p.c*p.b, p.b*p.d)
+		}
+		if p.ask != 0 {
+			if p.ask != lcm {
+				t.Errorf("%s: %s asks for the numerator over %d, not %d", name, question, p.ask, lcm)
+			}
+			want = Whole(want.Num * p.ask / want.Den)
+		}
+		limited = want
+	case Decimals:
+		want = Fraction(p.a*p.b, 10).AsDecimal()
+	case Binary, Hexadecimal:
+		base := map[Op]int{Binary: 2, Hexadecimal: 16}[op]
+		digits := strings.TrimSuffix(question, fmt.Sprintf(" base %d", base))
+		if n, err := strconv.ParseInt(digits, base, 64); err == nil {
+			want = Whole(int(n))
+		}
+	case PlaceValue:
+		var place string
+		var n int
+		fmt.Sscanf(question, "%s digit of %d", &place, &n)
+		digits := strconv.Itoa(n)
+		for i, name := range []string{"ones", "tens", "hundreds", "thousands"} {
+			if name == place && i < len(digits) {
+				want = Whole(int(digits[len(digits)-1-i] - '0'))
+			}
+		}
+		if n < 10 || n > MaxPlaceValue {
+			t.Errorf("%s: %s asks about a number out of range", name, question)
+		}
+	case Roman:
+		want = Whole(parseRoman(question))
+		if p.answer.Int() < 1 {
+			t.Errorf("%s: %s has no Roman numeral", name, question)
+		}
+	}
+	if p.answer != want {
+		t.Errorf("%s: %s = %s, want %s", name, question, p.answer, want)
+	}
+	if g.WholeAnswers && !p.answer.IsWhole() {
+		t.Errorf("%s: %s = %s is not a whole number", name, question, p.answer)
+	}
+	if p.a < 0 || p.b < 0 || p.answer.Less(Whole(0)) {
+		t.Errorf("%s: %s = %s has negative numbers", name, question, p.answer)
+	}
+	if g.Factor != 0 && (op == Multiply || op == Divide) {
+		if p.a != g.Factor && p.b != g.Factor {
+			t.Errorf("%s: %s is not in the %d times table", name, question, g.Factor)
+		}
+	} else if Whole(g.Max).Less(limited) {
+		t.Errorf("%s: %s = %s is out of range %d", name, question, p.answer, g.Max)
+	}
+}
+
+// parseRoman reads a Roman numeral independently of RomanNumeral, -1 means that
+// it is not written the usual way.
+func parseRoman(s string) int {
+	values := map[byte]int{'I': 1, 'V': 5, 'X': 10, 'L': 50, 'C': 100, 'D': 500, 'M': 1000}
+	n := 0
+	for i := 0; i < len(s); i++ {
+		v := values[s[i]]
+		if v == 0 {
+			return -1
+		}
+		if i+1 < len(s) && v < values[s[i+1]] {
+			n -= v
+		} else {
+			n += v
+		}
+	}
+	if n < 1 || RomanNumeral(n) != s {
+		return -1
+	}
+	return n
+}
+
+func TestRomanNumerals(t *testing.T) {
+	for n, want := range map[int]string{
+		1: "I", 4: "IV", 9: "IX", 14: "XIV", 40: "XL", 90: "XC", 400: "CD",
+		1994: "MCMXCIV", 2024: "MMXXIV", 3999: "MMMCMXCIX",
+	} {
+		if got := RomanNumeral(n); got != want {
+			t.Errorf("RomanNumeral(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
+
+func TestEveryProblemIsReachable(t *testing.T) {
+	for name, g := range generators() {
+		valid := make(map[Assignment]bool)
+		unseen := make(map[Assignment]bool)
+		for _, op := range g.Ops {
+			for _, p := range g.Problems(op) {
+				valid[p] = true
+				unseen[p] = true
+			}
+		}
+		rand := rand.New(rand.NewSource(1)).Int
+		// large sets like the place values need more tries
+		tries := 1000000
+		if 100*len(valid) > tries {
+			tries = 100 * len(valid)
+		}
+		for i := 0; i < tries && len(unseen) > 0; i++ {
+			a := g.Generate(rand)
+			if !valid[a] {
+				t.Errorf("%s: generated invalid problem %s", name, a.Question)
+			}
+			delete(unseen, a)
+		}
+		for p := range unseen {
+			t.Errorf("%s: %s is never generated", name, p.Question)
+		}
+	}
+}
+
+func TestByAnswerMakesAnswersEquallyLikely(t *testing.T) {
+	for _, op := range generatedOps {
+		g := Generator{Ops: []Op{op}, Max: 9}
+		answers := make(map[Number]bool)
+		for _, p := range g.Problems(op) {
+			answers[p.Answer] = true
+		}
+		counts := make(map[string]int)
+		const n = 50000
+		rand := rand.New(rand.NewSource(1)).Int
+		for i := 0; i < n; i++ {
+			counts[fmt.Sprint(g.Generate(rand).Answer)]++
+		}
+		histogram(t, fmt.Sprintf("answers of %s, max 9, by answer", op), counts)
+		checkUniform(t, op.String(), counts, len(answers), n)
+	}
+}
+
+func TestByProblemMakesProblemsEquallyLikely(t *testing.T) {
+	for _, op := range generatedOps {
+		g := Generator{Ops: []Op{op}, Max: 9, Weighting: ByProblem}
+		if len(g.Problems(op)) > 200 {
+			// too many problems to count each one often enough, they are
+			// only checked for reachability
+			continue
+		}
+		counts := make(map[string]int)
+		const n = 50000
+		rand := rand.New(rand.NewSource(1)).Int
+		for i := 0; i < n; i++ {
+			counts[g.Generate(rand).Question]++
+		}
+		histogram(t, fmt.Sprintf("problems of %s, max 9, by problem", op), counts)
+		checkUniform(t, op.String(), counts, len(g.Problems(op)), n)
+	}
+}
+
+func TestOpsAreWeightedByCount(t *testing.T) {
+	counts := make(map[string]int)
+	const n = 60000
+	rand := rand.New(rand.NewSource(1)).Int
+	for i := 0; i < n; i++ {
+		counts[Normal.Shots().Generate(rand).Op.String()]++
+	}
+	histogram(t, "operations of the shots", counts)
+	// + and - are in the list twice
+	for op, want := range map[string]int{"+": n / 3, "-": n / 3, "*": n / 6, "/": n / 6} {
+		if diff := counts[op] - want; diff < -want/10 || diff > want/10 {
+			t.Errorf("%s came up %d times, want about %d", op, counts[op], want)
+		}
+	}
+}
+
+// checkUniform makes sure that each of the k values came up and none of them
+// is off by more than 15 percent.
+func checkUniform(t *testing.T, name string, counts map[string]int, k, n int) {
+	t.Helper()
+	if len(counts) != k {
+		t.Errorf("%s: %d different values, want %d", name, len(counts), k)
+	}
+	want := n / k
+	for value, count := range counts {
+		if diff := count - want; diff < -want*15/100 || diff > want*15/100 {
+			t.Errorf("%s: %s came up %d times, want about %d", name, value, count, want)
+		}
+	}
+}
+
+func histogram(t *testing.T, title string, counts map[string]int) {
+	t.Helper()
+	var keys []string
+	max := 0
+	for key, count := range counts {
+		keys = append(keys, key)
+		if count > max {
+			max = count
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) < len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	text := title + "\n"
+	for _, key := range keys {
+		bar := strings.Repeat("#", 50*counts[key]/max)
+		text += fmt.Sprintf("%8s %6d %s\n", key, counts[key], bar)
+	}
+	t.Log(text)
+}
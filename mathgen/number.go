@@ -0,0 +1,131 @@
+package mathgen
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Number is an exact answer: a whole number, a fraction or a decimal. It is
+// stored as a fraction in lowest terms, so two Numbers with the same value are
+// equal. Decimals only differ in how they are written, e.g. 1.5 instead of 3/2.
+type Number struct {
+	Num, Den int
+	Decimal  bool
+}
+
+func Whole(n int) Number {
+	return Number{Num: n, Den: 1}
+}
+
+// Fraction returns num/den in lowest terms. den must not be 0.
+func Fraction(num, den int) Number {
+	if den < 0 {
+		num, den = -num, -den
+	}
+	g := gcd(abs(num), den)
+	return Number{Num: num / g, Den: den / g}
+}
+
+// AsDecimal returns n written with a decimal point. Whole numbers stay whole.
+func (n Number) AsDecimal() Number {
+	n.Decimal = n.Den != 1
+	return n
+}
+
+func (n Number) IsWhole() bool {
+	return n.Den == 1
+}
+
+// Int returns n rounded towards 0.
+func (n Number) Int() int {
+	return n.Num / n.Den
+}
+
+// Equal compares the values of n and m, a decimal and a fraction can be equal.
+func (n Number) Equal(m Number) bool {
+	return n.Num == m.Num && n.Den == m.Den
+}
+
+func (n Number) Less(m Number) bool {
+	return n.Num*m.Den < m.Num*n.Den
+}
+
+// String writes n the way the player types it: 3, 3/4 or 0.75.
+func (n Number) String() string {
+	if n.Den == 1 {
+		return strconv.Itoa(n.Num)
+	}
+	if n.Decimal {
+		digits := 1
+		for p := 10; digits <= 9; p, digits = p*10, digits+1 {
+			if p%n.Den == 0 {
+				v := abs(n.Num) * (p / n.Den)
+				sign := ""
+				if n.Num < 0 {
+					sign = "-"
+				}
+				frac := strings.TrimRight(fmt.Sprintf("%0*d", digits, v%p), "0")
+				return fmt.Sprintf("%s%d.%s", sign, v/p, frac)
+			}
+		}
+	}
+	return fmt.Sprintf("%d/%d", n.Num, n.Den)
+}
+
+// ParseNumber reads a whole number like 3, a fraction like 3/4 or a decimal
+// like 0.75.
+func ParseNumber(s string) (Number, error) {
+	if num, den, ok := strings.Cut(s, "/"); ok {
+		n, err1 := strconv.Atoi(num)
+		d, err2 := strconv.Atoi(den)
+		if err1 != nil || err2 != nil || d <= 0 {
+			return Number{}, errors.New("invalid fraction " + s)
+		}
+		return Fraction(n, d), nil
+	}
+	if whole, frac, ok := strings.Cut(s, "."); ok {
+		negative := strings.HasPrefix(whole, "-")
+		whole = strings.TrimPrefix(whole, "-")
+		if whole == "" {
+			whole = "0"
+		}
+		w, err1 := strconv.Atoi(whole)
+		f, err2 := strconv.Atoi(frac)
+		if err1 != nil || err2 != nil || w < 0 || f < 0 || len(frac) > 9 || strings.HasPrefix(frac, "+") {
+			return Number{}, errors.New("invalid decimal " + s)
+		}
+		p := 1
+		for range frac {
+			p *= 10
+		}
+		num := w*p + f
+		if negative {
+			num = -num
+		}
+		return Fraction(num, p).AsDecimal(), nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return Number{}, errors.New("invalid number " + s)
+	}
+	return Whole(n), nil
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a == 0 {
+		return 1
+	}
+	return a
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,67 @@
+package mathgen
+
+import "testing"
+
+func TestNumbersAreWrittenTheWayTheyAreTyped(t *testing.T) {
+	for _, test := range []struct {
+		n    Number
+		want string
+	}{
+		{Whole(0), "0"},
+		{Whole(12), "12"},
+		{Fraction(3, 4), "3/4"},
+		{Fraction(2, 4), "1/2"},
+		{Fraction(4, 2), "2"},
+		{Fraction(1, -2), "-1/2"},
+		{Fraction(3, 2).AsDecimal(), "1.5"},
+		{Fraction(1, 4).AsDecimal(), "0.25"},
+		{Fraction(-1, 20).AsDecimal(), "-0.05"},
+		{Fraction(10, 5).AsDecimal(), "2"},
+		{Fraction(1, 3).AsDecimal(), "1/3"},
+	} {
+		if got := test.n.String(); got != test.want {
+			t.Errorf("%#v is written as %q, want %q", test.n, got, test.want)
+		}
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	for _, test := range []struct {
+		text string
+		want Number
+	}{
+		{"0", Whole(0)},
+		{"7", Whole(7)},
+		{"3/4", Fraction(3, 4)},
+		{"6/8", Fraction(3, 4)},
+		{"0.75", Fraction(3, 4).AsDecimal()},
+		{".5", Fraction(1, 2).AsDecimal()},
+		{"-0.5", Fraction(-1, 2).AsDecimal()},
+		{"2.0", Whole(2)},
+	} {
+		got, err := ParseNumber(test.text)
+		if err != nil {
+			t.Errorf("%q: %v", test.text, err)
+		} else if got != test.want {
+			t.Errorf("%q is %#v, want %#v", test.text, got, test.want)
+		}
+	}
+	for _, text := range []string{"", "a", "1/0", "1/-2", "1/", "1.2.3", "1.-5", "1.+5", "--1.5"} {
+		if n, err := ParseNumber(text); err == nil {
+			t.Errorf("%q is %v, want an error", text, n)
+		}
+	}
+}
+
+func TestDecimalsEqualFractions(t *testing.T) {
+	half, _ := ParseNumber("0.5")
+	if !half.Equal(Fraction(1, 2)) {
+		t.Error("0.5 and 1/2 are not equal")
+	}
+	if half.Equal(Fraction(1, 3)) {
+		t.Error("0.5 and 1/3 are equal")
+	}
+	if !Fraction(1, 3).Less(half) || half.Less(Fraction(1, 3)) {
+		t.Error("1/3 is not less than 0.5")
+	}
+}
@@ -0,0 +1,39 @@
+package mathgen
+
+import "strings"
+
+// RomanNumeral writes n as a Roman numeral, e.g. 14 as XIV. 0 is the empty
+// string.
+func RomanNumeral(n int) string {
+	if n < 10 {
+		return convertDigit(n, "X", "V", "I")
+	}
+	if n < 100 {
+		return convertDigit(n/10, "C", "L", "X") + RomanNumeral(n%10)
+	}
+	if n < 1000 {
+		return convertDigit(n/100, "M", "D", "C") + RomanNumeral(n%100)
+	}
+	var result string
+	for n >= 1000 {
+		result += "M"
+		n -= 1000
+	}
+	return result + RomanNumeral(n)
+}
+
+func convertDigit(d int, high, mid, low string) string {
+	if d < 5 {
+		return convertDigitBelow5(d, mid, "", low)
+	} else {
+		return convertDigitBelow5(d-5, high, mid, low)
+	}
+}
+
+func convertDigitBelow5(d int, high, mid, low string) string {
+	if d == 4 {
+		return low + high
+	} else {
+		return mid + strings.Repeat(low, d)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gonutz/prototype/draw"
+)
+
+// The bitmap font has no radical sign. mathgen writes square roots as
+// "sqrt 49", they are shown as √49 with the sign drawn from lines. The font is
+// monospaced, so the sign takes the place of one character.
+const sqrtPrefix = "sqrt "
+
+// mathTextSize is the size of the text as drawMathText draws it.
+func mathTextSize(window draw.Window, text string, scale float32) (width, height int) {
+	return window.GetScaledTextSize(strings.ReplaceAll(text, sqrtPrefix, " "), scale)
+}
+
+// drawMathText draws the text like DrawScaledText, with radical signs over the
+// numbers that follow "sqrt ".
+func drawMathText(window draw.Window, text string, x, y int, scale float32, color draw.Color) {
+	shown := strings.ReplaceAll(text, sqrtPrefix, " ")
+	window.DrawScaledText(shown, x, y, scale, color)
+	if shown == text {
+		return
+	}
+	charW, h := window.GetScaledTextSize(" ", scale)
+	thickness := h/16 + 1
+	for rest, start := text, 0; ; {
+		i := strings.Index(rest, sqrtPrefix)
+		if i == -1 {
+			break
+		}
+		// the sign is in the space at start+i, the number after it ends at
+		// the next space
+		sign := start + i
+		end := strings.IndexByte(shown[sign+1:], ' ')
+		if end == -1 {
+			end = len(shown)
+		} else {
+			end += sign + 1
+		}
+		left, _ := window.GetScaledTextSize(shown[:sign], scale)
+		right, _ := window.GetScaledTextSize(shown[:end], scale)
+		left += x
+		right += x
+		top := y + h/8
+		points := [][2]int{
+			{left + charW/8, y + h*11/20},
+			{left + charW*3/8, y + h*17/20},
+			{left + charW*7/8, top},
+			{right, top},
+		}
+		for t := 0; t < thickness; t++ {
+			for p := 1; p < len(points); p++ {
+				from, to := points[p-1], points[p]
+				window.DrawLine(from[0]+t, from[1], to[0]+t, to[1], color)
+				window.DrawLine(from[0], from[1]+t, to[0], to[1]+t, color)
+			}
+		}
+		rest = rest[i+len(sqrtPrefix):]
+		start = sign + 1
+	}
+}
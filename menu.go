@@ -1,23 +1,78 @@
 package main
 
-import "github.com/gonutz/prototype/draw"
+import (
+	"fmt"
+	"time"
+
+	"github.com/gonutz/ld41/game"
+	"github.com/gonutz/ld41/mathgen"
+	"github.com/gonutz/prototype/draw"
+)
 
 type menuState struct {
-	hotItem int
-	items   []string
+	hotItem    int
+	sprint     int // index into sprintDurations
+	difficulty mathgen.Difficulty
+	mode       game.Mode
+	canResume  bool // the profile has a saved game
 }
 
+const (
+	menuContinue = iota // only shown if there is a saved game
+	menuStart
+	menuCoop
+	menuLANRace
+	menuDaily
+	menuDailyHistory
+	menuSprint
+	menuPractice
+	menuPacks
+	menuDifficulty
+	menuMode
+	menuProfile
+	menuAchievements
+	menuInstructions
+	menuHighScores
+	menuQuit
+	menuItemCount
+)
+
 func (s *menuState) enter(state) {
-	s.items = []string{
-		"Start Game",
-		"How to Play",
-		"High Scores",
-		"Quit",
+	s.canResume = hasSavedRun(currentProfile)
+	if s.canResume {
+		s.hotItem = menuContinue
+	} else if s.hotItem == menuContinue {
+		s.hotItem = menuStart
 	}
 }
 
 func (*menuState) leave() {}
 
+func (s *menuState) items() []string {
+	items := []string{
+		menuContinue:     "Continue",
+		menuStart:        "Start Game",
+		menuCoop:         "Co-op Game",
+		menuLANRace:      "LAN Race",
+		menuDaily:        "Daily Challenge",
+		menuDailyHistory: "Daily History",
+		menuSprint:       fmt.Sprintf("< Sprint %d s >", int(sprintDurations[s.sprint]/time.Second)),
+		menuPractice:     "Practice",
+		menuPacks:        "Problems: " + currentPack.String(),
+		menuDifficulty:   "< Difficulty: " + s.difficulty.String() + " >",
+		menuMode:         "< Mode: " + s.mode.String() + " >",
+		menuProfile:      "Profile: " + currentProfile,
+		menuAchievements: "Achievements",
+		menuInstructions: "How to Play",
+		menuHighScores:   "High Scores",
+		menuQuit:         "Quit",
+	}
+	if !s.canResume {
+		items[menuContinue] = ""
+	}
+	return items
+}
+
 func (s *menuState) update(window draw.Window) state {
 	var nextState state = menu
 	if window.WasKeyPressed(draw.KeyEscape) {
@@ -25,32 +80,160 @@ func (s *menuState) update(window draw.Window) state {
 	}
 	oldItem := s.hotItem
 	if window.WasKeyPressed(draw.KeyDown) {
-		s.hotItem = (s.hotItem + 1) % len(s.items)
+		s.hotItem = (s.hotItem + 1) % menuItemCount
+		if s.hotItem == menuContinue && !s.canResume {
+			s.hotItem++
+		}
 	}
 	if window.WasKeyPressed(draw.KeyUp) {
-		s.hotItem = (s.hotItem + len(s.items) - 1) % len(s.items)
+		s.hotItem = (s.hotItem + menuItemCount - 1) % menuItemCount
+		if s.hotItem == menuContinue && !s.canResume {
+			s.hotItem = menuItemCount - 1
+		}
+	}
+	if s.hotItem == menuSprint {
+		oldSprint := s.sprint
+		if window.WasKeyPressed(draw.KeyRight) {
+			s.sprint = (s.sprint + 1) % len(sprintDurations)
+		}
+		if window.WasKeyPressed(draw.KeyLeft) {
+			s.sprint = (s.sprint + len(sprintDurations) - 1) % len(sprintDurations)
+		}
+		if s.sprint != oldSprint {
+			window.PlaySoundFile("menu beep.wav")
+		}
+	}
+	if s.hotItem == menuDifficulty {
+		var i int
+		for j, d := range mathgen.Difficulties {
+			if d == s.difficulty {
+				i = j
+			}
+		}
+		n := len(mathgen.Difficulties)
+		if window.WasKeyPressed(draw.KeyRight) {
+			i = (i + 1) % n
+		}
+		if window.WasKeyPressed(draw.KeyLeft) {
+			i = (i + n - 1) % n
+		}
+		if mathgen.Difficulties[i] != s.difficulty {
+			s.difficulty = mathgen.Difficulties[i]
+			window.PlaySoundFile("menu beep.wav")
+		}
+	}
+	if s.hotItem == menuMode {
+		oldMode := s.mode
+		if window.WasKeyPressed(draw.KeyRight) {
+			s.mode = (s.mode + 1) % game.ModeCount
+		}
+		if window.WasKeyPressed(draw.KeyLeft) {
+			s.mode = (s.mode + game.ModeCount - 1) % game.ModeCount
+		}
+		if s.mode != oldMode {
+			window.PlaySoundFile("menu beep.wav")
+		}
 	}
 	if s.hotItem != oldItem {
 		window.PlaySoundFile("menu beep.wav")
 	}
 	if window.WasKeyPressed(draw.KeyEnter) || window.WasKeyPressed(draw.KeyNumEnter) {
 		switch s.hotItem {
-		case 0:
+		case menuContinue:
+			if run, settings, err := loadSavedRun(currentProfile); err == nil {
+				playing.settings = settings
+				playing.continued = run
+				nextState = playing
+			} else {
+				// the game cannot be continued, forget about it
+				deleteSavedRun(currentProfile)
+				s.canResume = false
+				s.hotItem = menuStart
+				window.PlaySoundFile("miss shot.wav")
+			}
+		case menuStart:
+			playing.settings = gameSettings{
+				players:    1,
+				problems:   currentPack,
+				difficulty: s.difficulty,
+				mode:       s.mode,
+			}
 			nextState = playing
-		case 1:
+		case menuCoop:
+			playing.settings = gameSettings{
+				players:    2,
+				problems:   currentPack,
+				difficulty: s.difficulty,
+				mode:       s.mode,
+			}
+			nextState = playing
+		case menuLANRace:
+			nextState = lobby
+		case menuDaily:
+			date := today()
+			playing.settings = gameSettings{
+				players: 1,
+				seed:    dailySeed(date),
+				daily:   date,
+			}
+			nextState = playing
+		case menuDailyHistory:
+			nextState = history
+		case menuSprint:
+			playing.settings = gameSettings{
+				players:    1,
+				sprint:     sprintDurations[s.sprint],
+				problems:   currentPack,
+				difficulty: s.difficulty,
+				mode:       s.mode,
+			}
+			nextState = playing
+		case menuPractice:
+			nextState = practice
+		case menuPacks:
+			nextState = packs
+		case menuProfile:
+			nextState = profile
+		case menuAchievements:
+			nextState = achievements
+		case menuInstructions:
+			playing.settings = gameSettings{
+				players:    1,
+				problems:   currentPack,
+				difficulty: s.difficulty,
+				mode:       s.mode,
+			}
 			nextState = instructions
-		case 2:
+		case menuHighScores:
+			playing.settings = gameSettings{
+				players:    1,
+				problems:   currentPack,
+				difficulty: s.difficulty,
+				mode:       s.mode,
+			}
 			nextState = dead
-		case 3:
+		case menuQuit:
 			window.Close()
 		}
 	}
 	// render
-	const textScale = 3
-	for i, item := range s.items {
+	const textScale = 2.1
+	items := s.items()
+	shown := 0
+	for _, item := range items {
+		if item != "" {
+			shown++
+		}
+	}
+	row := 0
+	for i, item := range items {
+		if item == "" {
+			continue
+		}
 		w, h := window.GetScaledTextSize(item, textScale)
 		x := (windowW - w) / 2
-		y := (windowH-h*len(s.items))/2 + i*h
+		y := (windowH-h*shown)/2 + row*h
+		row++
 		if i == s.hotItem {
 			window.FillRect(x-20, y, w+40, h, draw.DarkRed)
 		}
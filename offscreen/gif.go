@@ -0,0 +1,80 @@
+package offscreen
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+)
+
+// Recording collects frames for an animated GIF. To keep the memory small
+// while recording, frames are shrunk and reduced to a fixed palette when they
+// are added.
+type Recording struct {
+	delay  int // between frames, in 100ths of a second
+	shrink int // frames are this many times smaller in each direction
+	video  gif.GIF
+}
+
+// NewRecording starts a GIF that shows a frame every delay 100ths of a second.
+// Frames are shrunk by the given factor, 1 keeps their size.
+func NewRecording(delay, shrink int) *Recording {
+	if shrink < 1 {
+		shrink = 1
+	}
+	return &Recording{delay: delay, shrink: shrink}
+}
+
+// Add appends a copy of the image.
+func (r *Recording) Add(img *image.RGBA) {
+	w, h := img.Rect.Dx()/r.shrink, img.Rect.Dy()/r.shrink
+	frame := image.NewPaletted(image.Rect(0, 0, w, h), videoPalette)
+	n := uint32(r.shrink * r.shrink)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var red, green, blue uint32
+			for dy := 0; dy < r.shrink; dy++ {
+				p := img.Pix[img.PixOffset(img.Rect.Min.X+x*r.shrink, img.Rect.Min.Y+y*r.shrink+dy):]
+				for dx := 0; dx < r.shrink; dx++ {
+					red += uint32(p[4*dx])
+					green += uint32(p[4*dx+1])
+					blue += uint32(p[4*dx+2])
+				}
+			}
+			frame.Pix[frame.PixOffset(x, y)] = paletteIndex(red/n, green/n, blue/n)
+		}
+	}
+	r.video.Image = append(r.video.Image, frame)
+	r.video.Delay = append(r.video.Delay, r.delay)
+}
+
+// Frames is the number of frames added so far.
+func (r *Recording) Frames() int {
+	return len(r.video.Image)
+}
+
+// Encode writes the GIF, which loops forever.
+func (r *Recording) Encode(w io.Writer) error {
+	return gif.EncodeAll(w, &r.video)
+}
+
+// videoPalette has 6 levels of red, green and blue. Colors are mapped to it
+// directly, which is much faster than searching the closest palette color.
+var videoPalette = func() color.Palette {
+	var p color.Palette
+	for r := 0; r < 6; r++ {
+		for g := 0; g < 6; g++ {
+			for b := 0; b < 6; b++ {
+				p = append(p, color.RGBA{uint8(r * 51), uint8(g * 51), uint8(b * 51), 255})
+			}
+		}
+	}
+	return p
+}()
+
+func paletteIndex(r, g, b uint32) uint8 {
+	level := func(c uint32) uint32 {
+		return (c + 25) / 51
+	}
+	return uint8(36*level(r) + 6*level(g) + level(b))
+}
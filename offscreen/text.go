@@ -0,0 +1,185 @@
+package offscreen
+
+import (
+	"bytes"
+	_ "embed"
+	"image"
+	"image/png"
+	"math"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gonutz/prototype/draw"
+)
+
+// font.png is a copy of the draw package's bitmap font, which is not exported.
+// It has 16x16 white glyphs, each with a transparent margin of fontGlyphMargin
+// around it. TestFontIsTheDrawPackagesFont fails once the copies differ.
+//
+//go:embed font.png
+var fontPng []byte
+
+// These are the same as in the draw package, so that text has the same size as
+// on the desktop.
+const (
+	fontGlyphMargin   = 8
+	fontBaseScale     = 1.0 / 8
+	fontKerningFactor = 0.97
+)
+
+var (
+	loadFont  sync.Once
+	fontAlpha *image.Alpha
+)
+
+func font() *image.Alpha {
+	loadFont.Do(func() {
+		img, err := png.Decode(bytes.NewReader(fontPng))
+		if err != nil {
+			panic("offscreen: broken font.png: " + err.Error())
+		}
+		fontAlpha = image.NewAlpha(img.Bounds())
+		for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+			for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+				_, _, _, a := img.At(x, y).RGBA()
+				fontAlpha.Pix[fontAlpha.PixOffset(x, y)] = uint8(a >> 8)
+			}
+		}
+	})
+	return fontAlpha
+}
+
+// glyphSize is the size of a glyph in the font, without its margins.
+func glyphSize() (width, height int) {
+	b := font().Bounds()
+	return b.Dx()/16 - 2*fontGlyphMargin, b.Dy()/16 - 2*fontGlyphMargin
+}
+
+func (w *Window) GetTextSize(text string) (width, height int) {
+	return w.GetScaledTextSize(text, 1)
+}
+
+func (w *Window) GetScaledTextSize(text string, scale float32) (width, height int) {
+	scale *= fontBaseScale
+	lines := strings.Split(text, "\n")
+	maxLineW := 0
+	for _, line := range lines {
+		if n := utf8.RuneCountInString(line); n > maxLineW {
+			maxLineW = n
+		}
+	}
+	charW, charH := glyphSize()
+	width = int(float32(charW*maxLineW)*scale*fontKerningFactor + 0.5)
+	height = int(float32(charH*len(lines))*scale + 0.5)
+	return width, height
+}
+
+func (w *Window) DrawText(text string, x, y int, color draw.Color) {
+	w.DrawScaledText(text, x, y, 1, color)
+}
+
+func (w *Window) DrawScaledText(text string, x, y int, scale float32, color draw.Color) {
+	if len(text) == 0 || scale <= 0 {
+		return
+	}
+	scale *= fontBaseScale
+	charW, charH := glyphSize()
+	width := float32(charW) * scale * fontKerningFactor
+	height := float32(charH) * scale
+	destX, destY := float32(x), float32(y)
+	for _, r := range text {
+		if r == '\n' {
+			destX = float32(x)
+			destY += height
+			continue
+		}
+		w.drawGlyph(runeToFont(r), destX, destY, width, height, color)
+		destX += width
+	}
+}
+
+// drawGlyph scales the glyph to the destination rectangle. Each pixel is
+// covered by the average of the glyph pixels that fall into it, which keeps
+// small text readable.
+func (w *Window) drawGlyph(index int, x, y, width, height float32, color draw.Color) {
+	f := font()
+	charW, charH := glyphSize()
+	srcX := (index%16)*(charW+2*fontGlyphMargin) + fontGlyphMargin
+	srcY := (index/16)*(charH+2*fontGlyphMargin) + fontGlyphMargin
+	scaleX := float32(charW) / width
+	scaleY := float32(charH) / height
+
+	columns := spans(x, width, scaleX, charW)
+	rows := spans(y, height, scaleY, charH)
+	for _, row := range rows {
+		for _, col := range columns {
+			var sum float32
+			for _, sy := range row.src {
+				line := f.Pix[f.PixOffset(srcX, srcY+sy.pixel):]
+				for _, sx := range col.src {
+					sum += float32(line[sx.pixel]) * sx.weight * sy.weight
+				}
+			}
+			if sum > 0 {
+				w.blend(col.dest, row.dest, color, sum/255/(scaleX*scaleY))
+			}
+		}
+	}
+}
+
+// span is a destination pixel and the source pixels that it covers.
+type span struct {
+	dest int
+	src  []weighted
+}
+
+type weighted struct {
+	pixel  int
+	weight float32 // how much of the source pixel lies in the destination
+}
+
+// spans maps the destination pixels from start to start+length to the source
+// pixels 0 to n. scale is the number of source pixels per destination pixel.
+func spans(start, length, scale float32, n int) []span {
+	var list []span
+	for dest := int(math.Floor(float64(start))); float32(dest) < start+length; dest++ {
+		from := (float32(dest) - start) * scale
+		to := (float32(dest+1) - start) * scale
+		s := span{dest: dest}
+		for pixel := int(from); float32(pixel) < to && pixel < n; pixel++ {
+			if pixel < 0 {
+				continue
+			}
+			weight := min32(to, float32(pixel+1)) - max32(from, float32(pixel))
+			if weight > 0 {
+				s.src = append(s.src, weighted{pixel: pixel, weight: weight})
+			}
+		}
+		list = append(list, s)
+	}
+	return list
+}
+
+// runeToFont is the index of the rune's glyph in the font. Only ASCII is
+// supported, other runes use the replacement glyph.
+func runeToFont(r rune) int {
+	if 32 <= r && r <= 127 {
+		return int(r)
+	}
+	return 0
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
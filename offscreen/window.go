@@ -0,0 +1,412 @@
+// Package offscreen draws into an image instead of a window. Its Window
+// implements draw.Window with Go's image packages, so frames can be saved as
+// screenshots and videos or compared in tests. It draws like the desktop
+// version of draw, the text uses the same bitmap font.
+//
+// There is no real input, tests script it with Press, Type and Click before
+// each frame. Sounds are not played.
+package offscreen
+
+import (
+	"errors"
+	"image"
+	imagedraw "image/draw"
+	"image/png"
+	"math"
+
+	"github.com/gonutz/prototype/draw"
+)
+
+// Window is a draw.Window that renders into an image of a fixed size.
+type Window struct {
+	img        *image.RGBA
+	images     map[string]*image.NRGBA
+	closed     bool
+	fullscreen bool
+	pressed    map[draw.Key]bool
+	down       map[draw.Key]bool
+	chars      []rune
+	mouseDown  map[draw.MouseButton]bool
+	clicks     []draw.MouseClick
+	mouseX     int
+	mouseY     int
+	wheelX     float64
+	wheelY     float64
+}
+
+// New creates a black window of the given size. Images are loaded through
+// draw.OpenFile.
+func New(width, height int) *Window {
+	w := &Window{
+		img:       image.NewRGBA(image.Rect(0, 0, width, height)),
+		images:    make(map[string]*image.NRGBA),
+		pressed:   make(map[draw.Key]bool),
+		down:      make(map[draw.Key]bool),
+		mouseDown: make(map[draw.MouseButton]bool),
+	}
+	w.Frame()
+	return w
+}
+
+// Image is what was drawn so far in this frame.
+func (w *Window) Image() *image.RGBA {
+	return w.img
+}
+
+// Frame starts the next frame. Like a real window, the image is cleared to
+// black and the key presses, characters, clicks and mouse wheel movement of the
+// last frame are forgotten. Keys and mouse buttons stay down.
+func (w *Window) Frame() {
+	imagedraw.Draw(w.img, w.img.Bounds(), image.Black, image.Point{}, imagedraw.Src)
+	for key := range w.pressed {
+		delete(w.pressed, key)
+	}
+	w.chars = w.chars[:0]
+	w.clicks = w.clicks[:0]
+	w.wheelX, w.wheelY = 0, 0
+}
+
+// Press presses the keys in this frame. They stay down until Release.
+func (w *Window) Press(keys ...draw.Key) {
+	for _, key := range keys {
+		w.pressed[key] = true
+		w.down[key] = true
+	}
+}
+
+// Release lets go of the keys.
+func (w *Window) Release(keys ...draw.Key) {
+	for _, key := range keys {
+		delete(w.down, key)
+	}
+}
+
+// Type enters the text in this frame.
+func (w *Window) Type(text string) {
+	w.chars = append(w.chars, []rune(text)...)
+}
+
+// Click moves the mouse to x,y and clicks the button in this frame.
+func (w *Window) Click(x, y int, button draw.MouseButton) {
+	w.MoveMouse(x, y)
+	w.clicks = append(w.clicks, draw.MouseClick{X: x, Y: y, Button: button})
+}
+
+// MoveMouse moves the mouse to x,y.
+func (w *Window) MoveMouse(x, y int) {
+	w.mouseX, w.mouseY = x, y
+}
+
+// Closed reports whether Close was called.
+func (w *Window) Closed() bool {
+	return w.closed
+}
+
+func (w *Window) Close() {
+	w.closed = true
+}
+
+func (w *Window) SetIcon(path string) error {
+	_, err := w.load(path)
+	return err
+}
+
+func (w *Window) Size() (width, height int) {
+	return w.img.Bounds().Dx(), w.img.Bounds().Dy()
+}
+
+func (w *Window) SetFullscreen(f bool) {
+	w.fullscreen = f
+}
+
+func (w *Window) IsFullscreen() bool {
+	return w.fullscreen
+}
+
+func (w *Window) ShowCursor(show bool) {}
+
+func (w *Window) WasKeyPressed(key draw.Key) bool {
+	return w.pressed[key]
+}
+
+func (w *Window) IsKeyDown(key draw.Key) bool {
+	return w.down[key]
+}
+
+func (w *Window) Characters() string {
+	return string(w.chars)
+}
+
+func (w *Window) IsMouseDown(button draw.MouseButton) bool {
+	return w.mouseDown[button]
+}
+
+func (w *Window) Clicks() []draw.MouseClick {
+	return w.clicks
+}
+
+func (w *Window) MousePosition() (int, int) {
+	return w.mouseX, w.mouseY
+}
+
+func (w *Window) MouseWheelY() float64 {
+	return w.wheelY
+}
+
+func (w *Window) MouseWheelX() float64 {
+	return w.wheelX
+}
+
+func (w *Window) DrawPoint(x, y int, color draw.Color) {
+	w.blend(x, y, color, 1)
+}
+
+// DrawLine uses Bresenham's algorithm, both end points are drawn.
+func (w *Window) DrawLine(fromX, fromY, toX, toY int, color draw.Color) {
+	dx, dy := abs(toX-fromX), -abs(toY-fromY)
+	stepX, stepY := 1, 1
+	if toX < fromX {
+		stepX = -1
+	}
+	if toY < fromY {
+		stepY = -1
+	}
+	err := dx + dy
+	x, y := fromX, fromY
+	for {
+		w.blend(x, y, color, 1)
+		if x == toX && y == toY {
+			return
+		}
+		if e2 := 2 * err; e2 >= dy {
+			err += dy
+			x += stepX
+		} else {
+			err += dx
+			y += stepY
+		}
+	}
+}
+
+func (w *Window) DrawRect(x, y, width, height int, color draw.Color) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	w.FillRect(x, y, width, 1, color)
+	w.FillRect(x, y, 1, height, color)
+	w.FillRect(x+width-1, y, 1, height, color)
+	w.FillRect(x, y+height-1, width, 1, color)
+}
+
+func (w *Window) FillRect(x, y, width, height int, color draw.Color) {
+	r := image.Rect(x, y, x+width, y+height).Intersect(w.img.Bounds())
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			w.blend(px, py, color, 1)
+		}
+	}
+}
+
+func (w *Window) DrawEllipse(x, y, width, height int, color draw.Color) {
+	inside := func(px, py int) bool {
+		return inEllipse(px, py, x, y, width, height)
+	}
+	for py := y; py < y+height; py++ {
+		for px := x; px < x+width; px++ {
+			if inside(px, py) &&
+				!(inside(px-1, py) && inside(px+1, py) && inside(px, py-1) && inside(px, py+1)) {
+				w.blend(px, py, color, 1)
+			}
+		}
+	}
+}
+
+func (w *Window) FillEllipse(x, y, width, height int, color draw.Color) {
+	for py := y; py < y+height; py++ {
+		for px := x; px < x+width; px++ {
+			if inEllipse(px, py, x, y, width, height) {
+				w.blend(px, py, color, 1)
+			}
+		}
+	}
+}
+
+// inEllipse reports whether the center of pixel px,py is in the ellipse that
+// fills the rectangle.
+func inEllipse(px, py, x, y, width, height int) bool {
+	rx, ry := float64(width)/2, float64(height)/2
+	dx := (float64(px) + 0.5 - float64(x) - rx) / rx
+	dy := (float64(py) + 0.5 - float64(y) - ry) / ry
+	return dx*dx+dy*dy <= 1
+}
+
+func (w *Window) ImageSize(path string) (width, height int, err error) {
+	img, err := w.load(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	return img.Bounds().Dx(), img.Bounds().Dy(), nil
+}
+
+func (w *Window) DrawImageFile(path string, x, y int) error {
+	return w.drawImage(path, 0, 0, 0, 0, x, y, 0, 0, 0)
+}
+
+func (w *Window) DrawImageFileTo(path string, x, y, width, height, degrees int) error {
+	if width == 0 || height == 0 {
+		return nil
+	}
+	return w.drawImage(path, 0, 0, 0, 0, x, y, width, height, degrees)
+}
+
+func (w *Window) DrawImageFileRotated(path string, x, y, degrees int) error {
+	return w.drawImage(path, 0, 0, 0, 0, x, y, 0, 0, degrees)
+}
+
+func (w *Window) DrawImageFilePart(
+	path string,
+	sourceX, sourceY, sourceWidth, sourceHeight int,
+	destX, destY, destWidth, destHeight int,
+	rotationCWDeg int,
+) error {
+	if sourceWidth == 0 || sourceHeight == 0 || destWidth == 0 || destHeight == 0 {
+		return nil
+	}
+	return w.drawImage(
+		path,
+		sourceX, sourceY, sourceWidth, sourceHeight,
+		destX, destY, destWidth, destHeight,
+		rotationCWDeg,
+	)
+}
+
+// BlurImages is ignored, images are always drawn with the closest pixels.
+func (w *Window) BlurImages(blur bool) {}
+
+func (w *Window) PlaySoundFile(path string) error {
+	return nil
+}
+
+func (w *Window) load(path string) (*image.NRGBA, error) {
+	if img, ok := w.images[path]; ok {
+		return img, nil
+	}
+	if draw.OpenFile == nil {
+		return nil, errors.New("draw.OpenFile is not set")
+	}
+	f, err := draw.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	decoded, err := png.Decode(f)
+	if err != nil {
+		return nil, errors.New(path + ": " + err.Error())
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, decoded.Bounds().Dx(), decoded.Bounds().Dy()))
+	imagedraw.Draw(img, img.Bounds(), decoded, decoded.Bounds().Min, imagedraw.Src)
+	w.images[path] = img
+	return img, nil
+}
+
+// drawImage draws the source rectangle of the image to the destination
+// rectangle, rotated clockwise about the destination's center. A zero size
+// means the whole image.
+func (w *Window) drawImage(
+	path string,
+	srcX, srcY, srcW, srcH int,
+	x, y, width, height int,
+	degrees int,
+) error {
+	img, err := w.load(path)
+	if err != nil {
+		return err
+	}
+	if srcW == 0 {
+		srcW, srcH = img.Bounds().Dx(), img.Bounds().Dy()
+	}
+	if width == 0 {
+		width, height = img.Bounds().Dx(), img.Bounds().Dy()
+	}
+	if width <= 0 || height <= 0 || srcW <= 0 || srcH <= 0 {
+		return nil
+	}
+
+	// Every pixel in the rotated destination rectangle is mapped back into
+	// the source rectangle.
+	sin, cos := math.Sincos(float64(degrees) / 180 * math.Pi)
+	cx := float64(x) + float64(width)/2
+	cy := float64(y) + float64(height)/2
+	halfW, halfH := float64(width)/2, float64(height)/2
+	extentX := math.Abs(cos)*halfW + math.Abs(sin)*halfH
+	extentY := math.Abs(sin)*halfW + math.Abs(cos)*halfH
+	bounds := image.Rect(
+		int(math.Floor(cx-extentX)), int(math.Floor(cy-extentY)),
+		int(math.Ceil(cx+extentX)), int(math.Ceil(cy+extentY)),
+	).Intersect(w.img.Bounds())
+	scaleX := float64(srcW) / float64(width)
+	scaleY := float64(srcH) / float64(height)
+	for py := bounds.Min.Y; py < bounds.Max.Y; py++ {
+		for px := bounds.Min.X; px < bounds.Max.X; px++ {
+			dx, dy := float64(px)+0.5-cx, float64(py)+0.5-cy
+			u := cos*dx + sin*dy + halfW
+			v := -sin*dx + cos*dy + halfH
+			if u < 0 || v < 0 || u >= float64(width) || v >= float64(height) {
+				continue
+			}
+			sx := srcX + int(u*scaleX)
+			sy := srcY + int(v*scaleY)
+			if !(image.Point{sx, sy}.In(img.Rect)) {
+				continue
+			}
+			c := img.Pix[img.PixOffset(sx, sy):]
+			if c[3] == 0 {
+				continue
+			}
+			w.blend(px, py, draw.Color{
+				R: float32(c[0]) / 255,
+				G: float32(c[1]) / 255,
+				B: float32(c[2]) / 255,
+				A: float32(c[3]) / 255,
+			}, 1)
+		}
+	}
+	return nil
+}
+
+// blend draws the color over the pixel x,y with the color's alpha times
+// coverage.
+func (w *Window) blend(x, y int, color draw.Color, coverage float32) {
+	if !(image.Point{x, y}.In(w.img.Rect)) {
+		return
+	}
+	a := clamp(color.A * coverage)
+	if a == 0 {
+		return
+	}
+	p := w.img.Pix[w.img.PixOffset(x, y):]
+	mix := func(dst uint8, src float32) uint8 {
+		return uint8(float32(dst)*(1-a) + clamp(src)*a*255 + 0.5)
+	}
+	p[0] = mix(p[0], color.R)
+	p[1] = mix(p[1], color.G)
+	p[2] = mix(p[2], color.B)
+	p[3] = 255
+}
+
+func clamp(f float32) float32 {
+	if f < 0 {
+		return 0
+	}
+	if f > 1 {
+		return 1
+	}
+	return f
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,180 @@
+package offscreen
+
+import (
+	"bytes"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gonutz/prototype/draw"
+)
+
+func TestTextHasTheSizeOfTheDesktopFont(t *testing.T) {
+	w := New(100, 100)
+	// a glyph is 67x129 pixels, scaled by 2/8 and a kerning of 0.97
+	width, height := w.GetScaledTextSize("ab\nc", 2)
+	if width != 32 || height != 65 {
+		t.Errorf("the text is %dx%d but should be 32x65", width, height)
+	}
+	w.DrawScaledText("ab\nc", 10, 10, 2, draw.White)
+	drawn := image.Rectangle{}
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			if w.Image().RGBAAt(x, y).R > 0 {
+				drawn = drawn.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if !drawn.In(image.Rect(10, 10, 10+width, 10+height)) {
+		t.Errorf("the text was drawn to %v, outside of its size", drawn)
+	}
+}
+
+// TestFontIsTheDrawPackagesFont fails once an update of the draw package
+// changes its font, then font.png and the constants have to be copied again.
+func TestFontIsTheDrawPackagesFont(t *testing.T) {
+	out, err := exec.Command("go", "list", "-f", "{{.Dir}}", "github.com/gonutz/prototype/draw").Output()
+	if err != nil {
+		t.Skip("cannot find the draw package:", err)
+	}
+	dir := strings.TrimSpace(string(out))
+	drawFont, err := os.ReadFile(filepath.Join(dir, "font.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(drawFont, fontPng) {
+		t.Error("font.png differs from the draw package's font.png")
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(dir, "font.go"), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{
+		"fontGlyphMargin":   fontGlyphMargin,
+		"fontBaseScale":     fontBaseScale,
+		"fontKerningFactor": fontKerningFactor,
+	}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			spec := spec.(*ast.ValueSpec)
+			for i, name := range spec.Names {
+				ours, ok := want[name.Name]
+				if !ok || i >= len(spec.Values) {
+					continue
+				}
+				delete(want, name.Name)
+				tv, err := types.Eval(fset, nil, token.NoPos, types.ExprString(spec.Values[i]))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if theirs, _ := constant.Float64Val(tv.Value); theirs != ours {
+					t.Errorf("%s is %v in the draw package but %v here", name.Name, theirs, ours)
+				}
+			}
+		}
+	}
+	for name := range want {
+		t.Errorf("the draw package has no constant %s anymore", name)
+	}
+}
+
+func TestFillRectBlendsAndClips(t *testing.T) {
+	w := New(10, 10)
+	w.FillRect(5, 5, 100, 100, draw.RGBA(1, 0, 0, 0.5))
+	checkColor(t, w, 4, 4, color.RGBA{0, 0, 0, 255})
+	checkColor(t, w, 9, 9, color.RGBA{128, 0, 0, 255})
+	w.FillRect(5, 5, 100, 100, draw.RGBA(1, 0, 0, 0.5))
+	checkColor(t, w, 9, 9, color.RGBA{192, 0, 0, 255})
+}
+
+func TestImagesAreRotatedClockwiseAboutTheirCenter(t *testing.T) {
+	// the image is 4x2 with a red top-left pixel, turned by 90 degrees this
+	// pixel is at the top-right of the 2x4 area with the same center
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	img.SetNRGBA(0, 0, color.NRGBA{255, 0, 0, 255})
+	var file bytes.Buffer
+	png.Encode(&file, img)
+	defer func(open func(string) (io.ReadCloser, error)) { draw.OpenFile = open }(draw.OpenFile)
+	draw.OpenFile = func(path string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(file.Bytes())), nil
+	}
+
+	w := New(10, 10)
+	if err := w.DrawImageFileRotated("test.png", 4, 4, 90); err != nil {
+		t.Fatal(err)
+	}
+	checkColor(t, w, 6, 3, color.RGBA{255, 0, 0, 255})
+	checkColor(t, w, 5, 3, color.RGBA{255, 255, 255, 255})
+	checkColor(t, w, 5, 6, color.RGBA{255, 255, 255, 255})
+	checkColor(t, w, 4, 4, color.RGBA{0, 0, 0, 255})
+	checkColor(t, w, 7, 4, color.RGBA{0, 0, 0, 255})
+}
+
+func TestKeyPressesLastOneFrame(t *testing.T) {
+	w := New(10, 10)
+	w.Press(draw.KeyLeft)
+	w.Type("1/2")
+	w.Click(3, 4, draw.LeftButton)
+	if !w.WasKeyPressed(draw.KeyLeft) || w.Characters() != "1/2" || len(w.Clicks()) != 1 {
+		t.Fatal("the input is missing")
+	}
+	w.Frame()
+	if w.WasKeyPressed(draw.KeyLeft) || w.Characters() != "" || len(w.Clicks()) != 0 {
+		t.Error("the input of the last frame is still there")
+	}
+	if !w.IsKeyDown(draw.KeyLeft) {
+		t.Error("the key was let go without Release")
+	}
+	if x, y := w.MousePosition(); x != 3 || y != 4 {
+		t.Errorf("the mouse moved to %d,%d", x, y)
+	}
+}
+
+func TestRecordingIsAnAnimatedGIF(t *testing.T) {
+	w := New(20, 10)
+	r := NewRecording(5, 2)
+	r.Add(w.Image())
+	w.FillRect(0, 0, 20, 10, draw.White)
+	r.Add(w.Image())
+	var buf bytes.Buffer
+	if err := r.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	video, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(video.Image) != 2 || video.Config.Width != 10 || video.Config.Height != 5 {
+		t.Fatalf("the video has %d frames of %dx%d", len(video.Image), video.Config.Width, video.Config.Height)
+	}
+	if c := video.Image[1].At(3, 3); c != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("the second frame is %v instead of white", c)
+	}
+}
+
+func checkColor(t *testing.T, w *Window, x, y int, want color.RGBA) {
+	t.Helper()
+	if c := w.Image().RGBAAt(x, y); c != want {
+		t.Errorf("pixel %d,%d is %v but should be %v", x, y, c, want)
+	}
+}
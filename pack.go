@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gonutz/ld41/game"
+	"github.com/gonutz/ld41/mathgen"
+)
+
+// A problem pack is a teacher's own set of questions that replaces the
+// generated math problems. Packs are .txt or .json files in the packFolder.
+//
+// In a text pack, each line is one problem. The answer follows the last = sign
+// and may be followed by a weight and tags, separated by semicolons. Empty
+// lines and lines starting with # are ignored:
+//
+//	# percentages
+//	15% of 40 = 6
+//	1 km = ? m = 1000 ; weight=2 ; tags=units,length
+//	half of 3 = 1.5
+//	1/2 + 1/4 = 3/4
+//
+// A JSON pack looks like this, only question and answer are required:
+//
+//	{
+//	  "name": "Units",
+//	  "problems": [
+//	    {"question": "1 km = ? m", "answer": 1000, "weight": 2, "tags": ["length"]},
+//	    {"question": "1/2 + 1/4", "answer": "3/4"}
+//	  ]
+//	}
+//
+// Answers can be whole numbers, decimals or fractions. Problems with whole
+// answers from 0 to 9 are used for shooting, all others only for reloading.
+const (
+	packFolder         = "brainless_jogging_packs"
+	maxPackQuestionLen = 30
+	maxPackAnswer      = 99999
+	maxPackErrors      = 5 // only the first errors of a pack are reported
+)
+
+type problemPack struct {
+	name     string
+	file     string
+	problems []packProblem
+	errors   []string // the pack can only be used if this is empty
+}
+
+type packProblem struct {
+	question string
+	answer   mathgen.Number
+	weight   int
+	tags     []string
+}
+
+// packChoice is a pack together with the tag that its problems are filtered
+// by, the empty tag selects all problems.
+type packChoice struct {
+	pack *problemPack
+	tag  string
+}
+
+// currentPack is the problem source selected in the menu. If its pack is nil,
+// the generated math problems are used.
+var currentPack packChoice
+
+func (c packChoice) String() string {
+	if c.pack == nil {
+		return "Standard Math"
+	}
+	if c.tag != "" {
+		return c.pack.name + " (" + c.tag + ")"
+	}
+	return c.pack.name
+}
+
+// is reports whether both choices select the same problems. Packs are compared
+// by file because loadPacks creates new pack values every time.
+func (c packChoice) is(other packChoice) bool {
+	if c.pack == nil || other.pack == nil {
+		return c.pack == nil && other.pack == nil
+	}
+	return c.pack.file == other.pack.file && c.tag == other.tag
+}
+
+// loadPacks reads all packs in the packFolder, sorted by name.
+func loadPacks() []*problemPack {
+	var packs []*problemPack
+	for file, text := range loadFolder(packFolder) {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".txt":
+			packs = append(packs, parseTextPack(file, text))
+		case ".json":
+			packs = append(packs, parseJSONPack(file, text))
+		}
+	}
+	sort.Slice(packs, func(i, j int) bool {
+		return strings.ToLower(packs[i].name) < strings.ToLower(packs[j].name)
+	})
+	return packs
+}
+
+func parseTextPack(file, text string) *problemPack {
+	pack := &problemPack{
+		name: strings.TrimSuffix(file, filepath.Ext(file)),
+		file: file,
+	}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		where := fmt.Sprintf("line %d", i+1)
+		parts := strings.Split(line, ";")
+		equals := strings.LastIndex(parts[0], "=")
+		if equals == -1 {
+			pack.addError(where, "the answer must follow an = sign")
+			continue
+		}
+		answer, msg := parseAnswer(strings.TrimSpace(parts[0][equals+1:]))
+		p := packProblem{
+			question: strings.TrimSpace(parts[0][:equals]),
+			answer:   answer,
+			weight:   1,
+		}
+		for _, attribute := range parts[1:] {
+			if msg != "" {
+				break
+			}
+			key, value, _ := strings.Cut(attribute, "=")
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			switch key {
+			case "weight":
+				var err error
+				if p.weight, err = strconv.Atoi(value); err != nil {
+					msg = fmt.Sprintf("weight %q is not a whole number", value)
+				}
+			case "tags":
+				for _, tag := range strings.Split(value, ",") {
+					p.tags = append(p.tags, strings.TrimSpace(tag))
+				}
+			default:
+				msg = fmt.Sprintf("unknown attribute %q, use weight= or tags=", key)
+			}
+		}
+		if msg == "" {
+			msg = p.validate()
+		}
+		if msg != "" {
+			pack.addError(where, msg)
+			continue
+		}
+		pack.problems = append(pack.problems, p)
+	}
+	pack.validate()
+	return pack
+}
+
+func parseJSONPack(file, text string) *problemPack {
+	pack := &problemPack{
+		name: strings.TrimSuffix(file, filepath.Ext(file)),
+		file: file,
+	}
+	var data struct {
+		Name     string
+		Problems []struct {
+			Question string
+			Answer   interface{} // a number or a string like "3/4"
+			Weight   *int
+			Tags     []string
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(text)))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		pack.addError("", "invalid JSON, "+err.Error())
+		return pack
+	}
+	if data.Name != "" {
+		pack.name = data.Name
+	}
+	for i, problem := range data.Problems {
+		where := fmt.Sprintf("problem %d", i+1)
+		var answer mathgen.Number
+		msg := "the answer must be a number or a string"
+		switch a := problem.Answer.(type) {
+		case json.Number:
+			answer, msg = parseAnswer(a.String())
+		case string:
+			answer, msg = parseAnswer(strings.TrimSpace(a))
+		case nil:
+			msg = "the answer is missing"
+		}
+		p := packProblem{
+			question: strings.TrimSpace(problem.Question),
+			answer:   answer,
+			weight:   1,
+			tags:     problem.Tags,
+		}
+		if problem.Weight != nil {
+			p.weight = *problem.Weight
+		}
+		if msg == "" {
+			msg = p.validate()
+		}
+		if msg != "" {
+			pack.addError(where, msg)
+			continue
+		}
+		pack.problems = append(pack.problems, p)
+	}
+	pack.validate()
+	return pack
+}
+
+func parseAnswer(s string) (mathgen.Number, string) {
+	if s == "" {
+		return mathgen.Number{}, "the answer is missing"
+	}
+	n, err := mathgen.ParseNumber(s)
+	if err != nil {
+		return mathgen.Number{}, fmt.Sprintf("answer %q is not a number, decimal or fraction", s)
+	}
+	return n, ""
+}
+
+// validate returns a message describing what is wrong with the problem or ""
+// if it can be used in the game.
+func (p packProblem) validate() string {
+	if p.question == "" {
+		return "the question is missing"
+	}
+	if len(p.question) > maxPackQuestionLen {
+		return fmt.Sprintf("the question is longer than %d characters", maxPackQuestionLen)
+	}
+	for _, r := range p.question {
+		if r < 32 || r > 126 {
+			return fmt.Sprintf("the question contains %q which cannot be displayed", r)
+		}
+	}
+	if p.answer.Less(mathgen.Whole(0)) {
+		return "the answer must not be negative, there is no minus key in the game"
+	}
+	if mathgen.Whole(maxPackAnswer).Less(p.answer) {
+		return fmt.Sprintf("the answer must not be greater than %d", maxPackAnswer)
+	}
+	if p.weight < 1 {
+		return "the weight must be at least 1"
+	}
+	for _, tag := range p.tags {
+		if tag == "" {
+			return "tags must not be empty"
+		}
+	}
+	return ""
+}
+
+// validate checks that the pack can be played.
+func (pack *problemPack) validate() {
+	if len(pack.problems) == 0 && len(pack.errors) == 0 {
+		pack.addError("", "the pack has no problems")
+	} else if len(pack.shotProblems("")) == 0 {
+		pack.addError("", "at least one answer must be from 0 to 9, these are used for shooting")
+	}
+}
+
+func (pack *problemPack) addError(where, msg string) {
+	if len(pack.errors) == maxPackErrors {
+		pack.errors = append(pack.errors, "...")
+	}
+	if len(pack.errors) > maxPackErrors {
+		return
+	}
+	if where != "" {
+		where = " " + where
+	}
+	pack.errors = append(pack.errors, pack.file+where+": "+msg)
+}
+
+func (pack *problemPack) valid() bool {
+	return len(pack.errors) == 0
+}
+
+// tags returns the tags that can be used to filter the pack. Tags without a
+// problem that can be shot are left out.
+func (pack *problemPack) tags() []string {
+	var tags []string
+	for _, p := range pack.problems {
+		for _, tag := range p.tags {
+			i := sort.SearchStrings(tags, tag)
+			if (i == len(tags) || tags[i] != tag) && len(pack.shotProblems(tag)) > 0 {
+				tags = append(tags[:i], append([]string{tag}, tags[i:]...)...)
+			}
+		}
+	}
+	return tags
+}
+
+// tagged returns the problems that have the tag, all for an empty tag.
+func (pack *problemPack) tagged(tag string) []packProblem {
+	if tag == "" {
+		return pack.problems
+	}
+	var list []packProblem
+	for _, p := range pack.problems {
+		for _, t := range p.tags {
+			if t == tag {
+				list = append(list, p)
+				break
+			}
+		}
+	}
+	return list
+}
+
+func (pack *problemPack) shotProblems(tag string) []packProblem {
+	var list []packProblem
+	for _, p := range pack.tagged(tag) {
+		if p.answer.IsWhole() && p.answer.Int() <= 9 {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// generators returns the sources for the player's shot and reload assignments.
+// If wholeAnswers is set, reloads only use problems with whole number answers.
+func (c packChoice) generators(wholeAnswers bool) (shots, reloads game.Source) {
+	reloadProblems := c.pack.tagged(c.tag)
+	if wholeAnswers {
+		var whole []packProblem
+		for _, p := range reloadProblems {
+			if p.answer.IsWhole() {
+				whole = append(whole, p)
+			}
+		}
+		reloadProblems = whole
+	}
+	return packGenerator{c.pack.shotProblems(c.tag)}, packGenerator{reloadProblems}
+}
+
+// packGenerator picks random problems from a pack, problems with a higher
+// weight are picked more often.
+type packGenerator struct {
+	problems []packProblem
+}
+
+func (g packGenerator) Generate(rand func() int) mathgen.Assignment {
+	total := 0
+	for _, p := range g.problems {
+		total += p.weight
+	}
+	r := rand() % total
+	for _, p := range g.problems {
+		if r < p.weight {
+			return mathgen.Assignment{
+				Question: p.question,
+				Answer:   p.answer,
+				Op:       mathgen.Custom,
+			}
+		}
+		r -= p.weight
+	}
+	panic("unreachable")
+}
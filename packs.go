@@ -0,0 +1,138 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gonutz/prototype/draw"
+)
+
+const visiblePacks = 9
+
+// packsState lets the player choose a problem pack, or the standard math
+// problems, for the next games. Packs with tags can be narrowed down to one
+// tag with Left/Right.
+type packsState struct {
+	hotRow int // 0 is the standard math, the packs follow
+	packs  []*problemPack
+	tags   []int // selected tag of each pack, 0 means all, i means tags()[i-1]
+}
+
+func (s *packsState) enter(state) {
+	s.packs = loadPacks()
+	s.tags = make([]int, len(s.packs))
+	s.hotRow = 0
+	for i, pack := range s.packs {
+		if currentPack.pack != nil && pack.file == currentPack.pack.file {
+			s.hotRow = i + 1
+			for t, tag := range pack.tags() {
+				if tag == currentPack.tag {
+					s.tags[i] = t + 1
+				}
+			}
+		}
+	}
+}
+
+func (*packsState) leave() {}
+
+func (s *packsState) choice(row int) packChoice {
+	if row == 0 {
+		return packChoice{}
+	}
+	pack := s.packs[row-1]
+	c := packChoice{pack: pack}
+	if t := s.tags[row-1]; t > 0 {
+		c.tag = pack.tags()[t-1]
+	}
+	return c
+}
+
+func (s *packsState) update(window draw.Window) state {
+	if window.WasKeyPressed(draw.KeyEscape) {
+		return menu
+	}
+	rowCount := len(s.packs) + 1
+	oldRow := s.hotRow
+	if window.WasKeyPressed(draw.KeyDown) {
+		s.hotRow = (s.hotRow + 1) % rowCount
+	}
+	if window.WasKeyPressed(draw.KeyUp) {
+		s.hotRow = (s.hotRow + rowCount - 1) % rowCount
+	}
+	if s.hotRow != oldRow {
+		window.PlaySoundFile("menu beep.wav")
+	}
+	if s.hotRow > 0 {
+		pack := s.packs[s.hotRow-1]
+		tagCount := len(pack.tags()) + 1
+		tag := &s.tags[s.hotRow-1]
+		oldTag := *tag
+		if window.WasKeyPressed(draw.KeyRight) {
+			*tag = (*tag + 1) % tagCount
+		}
+		if window.WasKeyPressed(draw.KeyLeft) {
+			*tag = (*tag + tagCount - 1) % tagCount
+		}
+		if *tag != oldTag {
+			window.PlaySoundFile("menu beep.wav")
+		}
+	}
+	if window.WasKeyPressed(draw.KeyEnter) || window.WasKeyPressed(draw.KeyNumEnter) {
+		if s.hotRow == 0 || s.packs[s.hotRow-1].valid() {
+			currentPack = s.choice(s.hotRow)
+			return menu
+		}
+		window.PlaySoundFile("miss shot.wav")
+	}
+	// render
+	const (
+		title      = "Problem Packs"
+		titleScale = 3
+		rowScale   = 2
+		errorScale = 1.5
+	)
+	w, h := window.GetScaledTextSize(title, titleScale)
+	window.DrawScaledText(title, (windowW-w)/2, 30, titleScale, draw.White)
+	first := 0
+	if s.hotRow >= visiblePacks {
+		first = s.hotRow - visiblePacks + 1
+	}
+	_, lineH := window.GetScaledTextSize("A", rowScale)
+	for row := first; row < rowCount && row < first+visiblePacks; row++ {
+		text := s.choice(row).String()
+		if row > 0 && len(s.packs[row-1].tags()) > 0 {
+			text = "< " + text + " >"
+		}
+		color := draw.White
+		if row > 0 && !s.packs[row-1].valid() {
+			color = draw.Gray
+		}
+		if s.choice(row).is(currentPack) {
+			color = draw.Yellow
+		}
+		w, _ := window.GetScaledTextSize(text, rowScale)
+		x := (windowW - w) / 2
+		y := 30 + h + 30 + (row-first)*lineH
+		if row == s.hotRow {
+			window.FillRect(x-20, y, w+40, lineH, draw.DarkRed)
+		}
+		window.DrawScaledText(text, x, y, rowScale, color)
+	}
+	var message []string
+	if len(s.packs) == 0 {
+		if folder := folderPath(packFolder); folder != "" {
+			message = []string{"Put .txt or .json problem packs into", folder}
+		} else {
+			message = []string{"Problem packs can only be used in the desktop version."}
+		}
+	}
+	messageColor := draw.Gray
+	if s.hotRow > 0 && !s.packs[s.hotRow-1].valid() {
+		message = s.packs[s.hotRow-1].errors
+		messageColor = draw.Red
+	}
+	text := strings.Join(message, "\n")
+	w, _ = window.GetScaledTextSize(text, errorScale)
+	window.DrawScaledText(text, (windowW-w)/2, 30+h+30+visiblePacks*lineH+20, errorScale, messageColor)
+	return packs
+}
@@ -2,222 +2,325 @@ package main
 
 import (
 	"fmt"
-	"github.com/gonutz/prototype/draw"
 	"math/rand"
 	"time"
+
+	"github.com/gonutz/ld41/game"
+	"github.com/gonutz/ld41/mathgen"
+	"github.com/gonutz/prototype/draw"
 )
 
 const (
-	playerSpeed          = 4
-	playerW, playerH     = 172, 207
-	playerHeadH          = 60
-	bulletShootOffsetY   = 103
-	bulletW, bulletH     = 27, 9
-	zombieW, zombieH     = 116, 218
 	deadHeadW, deadHeadH = 87, 103
-	zombieSpawnReduction = 0.97
-	zombieSpawnMin       = 1000 * time.Millisecond
-	zombieSpawnMax       = 2000 * time.Millisecond
-	playerWalkFrames     = 4
 	bloodW, bloodH       = 24, 20
 	zombieDeathSounds    = 5
+	maxQuestionW         = 400 // wider questions are drawn smaller
+	// maxLag is the most time that is simulated in one frame, after a longer
+	// hiccup the game slows down instead of jumping ahead
+	maxLag = 250 * time.Millisecond
 )
 
-type torsoState int
+// timeNow is where the game gets the time from. Tests replace it with a clock
+// that advances one step per frame.
+var timeNow = time.Now
 
-const (
-	idle torsoState = iota
-	reloading
-	waitingToReload
-	shooting
-	realizing
-	aimingAtHead
-	bleeding
-)
-
-func dying(s torsoState) bool {
-	return s >= realizing
+// gameSettings are chosen before the game starts.
+type gameSettings struct {
+	players int           // 2 for co-op games
+	seed    int64         // seed for zombies and assignments, 0 means random
+	versus  *versusConn   // the opponent in a LAN race, nil otherwise
+	daily   string        // date of the daily challenge, empty otherwise
+	sprint  time.Duration // length of a sprint, 0 for the survival game
+	// problems replace the generated math problems if the pack is not nil
+	problems   packChoice
+	difficulty mathgen.Difficulty
+	mode       game.Mode // how the players answer
 }
 
+// playingState runs the game.World in steps of game.Tick, independent of the
+// frame rate, and draws it in between.
 type playingState struct {
-	playerX, playerY int
-	playerFacingLeft bool
-	playerWalkFrame  int
-	playerWalkTime   int
-	generator        mathGenerator
-	assignment       assignment
-	bullets          []bullet
-	zombies          []zombie
-	numbers          []fadingNumber
-	nextZombie       int // time until next zombie spawns
-	shootBan         int // time until shooting is allowed after wrong number
-	score            int
-	zombieSpawnDelay struct {
-		minFrames, maxFrames float32
-	}
-	torso          torsoState
-	torsoTime      int
+	settings       gameSettings // set this before entering the state
+	world          *game.World
+	controls       []controls // for each player
+	input          game.Input // what happened since the last step
+	lastFrame      time.Time
+	lag            time.Duration // time that is not simulated yet
+	numbers        []fadingNumber
 	blood          []bloodParticle
 	leaveStateTime int
+	versusOver     string          // result of a LAN race once it is decided
+	review         *reviewSchedule // nil in co-op and in seeded games
+	achievements   *achievementTracker
+	sounds         soundEffects
+	continued      *savedRun // set this to continue a saved game when entering
+	paused         bool
+	pauseItem      int
+	saved          bool // the game was saved when it was quit
 }
 
 func (s *playingState) enter(state) {
-	s.playerX = (windowW - playerW) / 2
-	s.playerY = windowH - playerH - 100
-	s.playerFacingLeft = false
-	s.playerWalkFrame = 0
-	s.playerWalkTime = 0
-	s.generator = mathGenerator{
-		ops: []mathOp{add, subtract, add, subtract, multiply, divide},
-		max: 9,
-	}
-	s.assignment = s.generator.generate(rand.Int)
-	s.bullets = nil
-	s.zombies = nil
+	if s.settings.players == 2 {
+		s.controls = []controls{leftKeys, numpadKeys}
+	} else {
+		s.controls = []controls{allKeys}
+	}
+	// missed facts are only brought back if one player plays a random game,
+	// seeded games have to produce the same assignments for everybody
+	s.review = nil
+	if s.settings.players != 2 && s.settings.seed == 0 && s.settings.problems.pack == nil {
+		s.review = loadReviews(currentProfile)
+		// a continued game is still in the session that it was started in
+		if s.continued == nil {
+			s.review.startSession()
+		}
+	}
+	shots, reloads := s.generators()
+	s.world = nil
+	if s.continued != nil {
+		// a broken save starts a new game instead
+		s.world, _ = game.Load(s.continued.World, shots, reloads)
+		deleteSavedRun(currentProfile)
+	}
+	continued := s.world != nil
+	if !continued {
+		seed := s.settings.seed
+		if seed == 0 {
+			seed = rand.Int63()
+		}
+		s.world = game.New(game.Settings{
+			Players: len(s.controls),
+			Seed:    seed,
+			Sprint:  s.settings.sprint,
+			Mode:    s.settings.mode,
+			Choices: s.choiceCount(),
+			Shots:   shots,
+			Reloads: reloads,
+		})
+	}
+	s.input = game.Input{Players: make([]game.PlayerInput, len(s.controls))}
+	s.lastFrame = timeNow()
+	s.lag = 0
 	s.numbers = nil
-	s.nextZombie = 0
-	s.shootBan = 0
-	s.score = 0
-	s.zombieSpawnDelay.minFrames = float32(frames(zombieSpawnMin))
-	s.zombieSpawnDelay.maxFrames = float32(frames(zombieSpawnMax))
-	s.newZombie()
-	s.torso = idle
-	s.torsoTime = 0
 	s.blood = nil
 	s.leaveStateTime = -1
+	s.versusOver = ""
+	s.achievements = loadAchievements(currentProfile)
+	s.achievements.startGame(s.settings)
+	if continued && s.continued.CleanKills != nil {
+		s.achievements.cleanKills = s.continued.CleanKills
+		s.achievements.kills = s.continued.Kills
+	}
+	s.continued = nil
+	s.paused = false
+	s.saved = false
+	s.sounds = soundEffects{}
+	s.world.Events.Subscribe(s.sounds.handle)
+	s.world.Events.Subscribe(s.achievements.handle)
+	s.world.Events.Subscribe(s.handle)
+}
+
+// generators returns the sources of the shot and reload assignments for this
+// game.
+func (s *playingState) generators() (shots, reloads game.Source) {
+	if s.settings.problems.pack != nil {
+		return s.settings.problems.generators(s.settings.players == 2)
+	}
+	shotMath, reloadMath := s.settings.difficulty.Shots(), s.settings.difficulty.Reloads()
+	// in co-op, the two halves of the keyboard share the typed . and / so
+	// the players cannot use them
+	reloadMath.WholeAnswers = s.settings.players == 2
+	if s.review != nil {
+		shotMath.Review, shotMath.ReviewShare = s.review, reviewShare
+		reloadMath.Review, reloadMath.ReviewShare = s.review, reviewShare
+	}
+	return shotMath, reloadMath
 }
 
-func (*playingState) leave() {}
+// choiceCount is the number of signs to pick the answer from, there are fewer
+// on the easy difficulty.
+func (s *playingState) choiceCount() int {
+	if s.settings.difficulty == mathgen.Easy {
+		return 3
+	}
+	return 4
+}
+
+// showsCursor is true if the player picks answers with the mouse.
+func (s *playingState) showsCursor() bool {
+	return s.settings.mode == game.MultipleChoice
+}
 
-var fireKeys = [10][2]draw.Key{
-	{draw.Key0, draw.KeyNum0},
-	{draw.Key1, draw.KeyNum1},
-	{draw.Key2, draw.KeyNum2},
-	{draw.Key3, draw.KeyNum3},
-	{draw.Key4, draw.KeyNum4},
-	{draw.Key5, draw.KeyNum5},
-	{draw.Key6, draw.KeyNum6},
-	{draw.Key7, draw.KeyNum7},
-	{draw.Key8, draw.KeyNum8},
-	{draw.Key9, draw.KeyNum9},
+func (s *playingState) leave() {
+	if s.settings.versus != nil {
+		s.settings.versus.close()
+		s.settings.versus = nil
+	}
+	if s.review != nil {
+		s.review.save()
+	}
+	if !s.saved {
+		s.world.End()
+	}
+	s.achievements.save()
 }
 
 func (s *playingState) update(window draw.Window) state {
-	// handle input
+	if s.paused {
+		return s.updatePause(window)
+	}
 	if window.WasKeyPressed(draw.KeyEscape) {
-		if dying(s.torso) {
+		if s.settings.versus != nil {
+			return lobby
+		}
+		if s.world.AllDying() {
 			return dead
-		} else {
-			return menu
 		}
+		// the Escape press that opens the pause menu must not close it again
+		s.paused = true
+		s.pauseItem = pauseResume
+		s.renderPause(window)
+		return playing
 	}
-	// shoot or miss
-	s.shootBan--
-	if s.shootBan < 0 {
-		s.shootBan = 0
-	}
-	if !dying(s.torso) && s.shootBan <= 0 {
-		wrongNumber := false
-		for n, keys := range fireKeys {
-			if window.WasKeyPressed(keys[0]) || window.WasKeyPressed(keys[1]) {
-				if n != s.assignment.answer {
-					wrongNumber = true
-					window.PlaySoundFile("miss shot.wav")
-					s.addFadingNumber(n, draw.Red)
-					s.shootBan = frames(500 * time.Millisecond)
-					break
-				}
-			}
+	s.readInput(window)
+
+	// the world moves in fixed steps, as many as fit into the time since the
+	// last frame
+	now := timeNow()
+	s.lag += now.Sub(s.lastFrame)
+	s.lastFrame = now
+	if s.lag > maxLag {
+		s.lag = maxLag
+	}
+	for s.lag >= game.Tick {
+		s.lag -= game.Tick
+		if next := s.step(); next != playing {
+			return next
 		}
-		if !wrongNumber {
-			keys := fireKeys[s.assignment.answer]
-			if window.WasKeyPressed(keys[0]) || window.WasKeyPressed(keys[1]) {
-				// add the number before shooting, shooting generates a new one
-				s.addFadingNumber(s.assignment.answer, draw.Green)
-				s.shoot(window)
-			}
+	}
+
+	s.render(window, float32(s.lag)/float32(game.Tick))
+	return playing
+}
+
+const (
+	pauseResume = iota
+	pauseSaveAndQuit
+	pauseItemCount
+)
+
+// updatePause shows the pause menu on top of the frozen game. Quitting saves
+// the game so it can be continued from the main menu.
+func (s *playingState) updatePause(window draw.Window) state {
+	oldItem := s.pauseItem
+	if window.WasKeyPressed(draw.KeyDown) {
+		s.pauseItem = (s.pauseItem + 1) % pauseItemCount
+	}
+	if window.WasKeyPressed(draw.KeyUp) {
+		s.pauseItem = (s.pauseItem + pauseItemCount - 1) % pauseItemCount
+	}
+	if s.pauseItem != oldItem {
+		window.PlaySoundFile("menu beep.wav")
+	}
+	resume := window.WasKeyPressed(draw.KeyEscape)
+	if window.WasKeyPressed(draw.KeyEnter) || window.WasKeyPressed(draw.KeyNumEnter) {
+		switch s.pauseItem {
+		case pauseResume:
+			resume = true
+		case pauseSaveAndQuit:
+			s.saveRun()
+			s.saved = true
+			return menu
 		}
 	}
-	// move left/right
-	walking := false
-	if !dying(s.torso) {
-		const margin = -50
-		if window.IsKeyDown(draw.KeyLeft) || window.IsKeyDown(draw.KeyA) {
-			walking = true
-			s.playerX -= playerSpeed
-			if s.playerX < margin {
-				s.playerX = margin
-			}
-			s.playerFacingLeft = true
-		} else if window.IsKeyDown(draw.KeyRight) || window.IsKeyDown(draw.KeyD) {
-			walking = true
-			s.playerX += playerSpeed
-			if s.playerX+playerW > windowW-margin {
-				s.playerX = windowW - margin - playerW
+	if resume {
+		s.paused = false
+		// the time in the pause menu is not simulated
+		s.lastFrame = timeNow()
+	}
+	s.renderPause(window)
+	return playing
+}
+
+// renderPause draws the pause menu on top of the frozen game.
+func (s *playingState) renderPause(window draw.Window) {
+	s.render(window, float32(s.lag)/float32(game.Tick))
+	window.FillRect(0, 0, windowW, windowH, draw.RGBA(0, 0, 0, 0.6))
+	const titleScale, textScale = 4, 2.5
+	title := "Paused"
+	w, h := window.GetScaledTextSize(title, titleScale)
+	y := windowH/2 - 2*h
+	window.DrawScaledText(title, (windowW-w)/2, y, titleScale, draw.White)
+	y += h + 20
+	items := [pauseItemCount]string{
+		pauseResume:      "Resume",
+		pauseSaveAndQuit: "Save and Quit",
+	}
+	for i, item := range items {
+		w, h := window.GetScaledTextSize(item, textScale)
+		x := (windowW - w) / 2
+		if i == s.pauseItem {
+			window.FillRect(x-20, y, w+40, h, draw.DarkRed)
+		}
+		window.DrawScaledText(item, x, y, textScale, draw.White)
+		y += h
+	}
+}
+
+// readInput collects the players' input until the next step.
+func (s *playingState) readInput(window draw.Window) {
+	for i := range s.controls {
+		s.controls[i].read(window, &s.input.Players[i])
+	}
+	for _, click := range window.Clicks() {
+		if click.Button == draw.LeftButton {
+			if i, choice := s.clickedChoice(window, click.X, click.Y); i != -1 {
+				s.input.Players[i].ClickedChoice = choice + 1
 			}
-			s.playerFacingLeft = false
 		}
 	}
-	if walking {
-		s.playerWalkTime--
-		if s.playerWalkTime <= 0 {
-			s.playerWalkFrame = (s.playerWalkFrame + 1) % playerWalkFrames
-			s.playerWalkTime = frames(100 * time.Millisecond)
+}
+
+// clickedChoice returns the player and the index of the sign at x,y. If the
+// players' signs overlap, the first player gets the click. The player is -1 if
+// no sign is there.
+func (s *playingState) clickedChoice(window draw.Window, x, y int) (player, choice int) {
+	for i := range s.controls {
+		for j, r := range choiceRects(window, &s.world.Players[i]) {
+			if r.contains(x, y) {
+				return i, j
+			}
 		}
-	} else {
-		s.playerWalkFrame = 0
-		s.playerWalkTime = 0
 	}
+	return -1, 0
+}
 
-	// update world
+// step advances the world and the visual effects by one game.Tick and returns
+// the next state.
+func (s *playingState) step() state {
+	if s.settings.versus != nil {
+		s.receiveFromOpponent()
+	}
+	wasTimeUp := s.world.TimeUp()
+	s.world.Step(s.input)
+	for i, in := range s.input.Players {
+		// only the keys that are held down carry over to the next step
+		s.input.Players[i] = game.PlayerInput{Left: in.Left, Right: in.Right}
+	}
+	if !wasTimeUp && s.world.TimeUp() {
+		s.leaveStateTime = game.Ticks(2 * time.Second)
+	}
 	if s.leaveStateTime > 0 {
 		s.leaveStateTime--
 		if s.leaveStateTime <= 0 {
-			return dead
-		}
-	}
-	// shoot bullets
-	n := 0
-	for i := range s.bullets {
-		b := &s.bullets[i]
-		bulletHitbox := rectangle{
-			x: b.x,
-			y: b.y,
-			w: bulletW + abs(b.dx),
-			h: bulletH,
-		}
-		if b.dx < 0 {
-			bulletHitbox.x += b.dx
-		}
-		b.x += b.dx
-		victimIndex := -1
-		for i, z := range s.zombies {
-			hitbox := rectangle{
-				x: z.x + zombieW/4,
-				y: z.y,
-				w: zombieW / 2,
-				h: zombieH,
-			}
-			if overlap(bulletHitbox, hitbox) {
-				if victimIndex == -1 ||
-					(b.dx > 0 && z.x < s.zombies[victimIndex].x) ||
-					(b.dx < 0 && z.x > s.zombies[victimIndex].x) {
-					victimIndex = i
-				}
+			if s.settings.versus != nil {
+				return lobby
 			}
-		}
-		if victimIndex != -1 {
-			s.killZombie(victimIndex)
-			window.PlaySoundFile(fmt.Sprintf("zombie death %d.wav", rand.Intn(zombieDeathSounds)))
-		}
-		if victimIndex == -1 && (-100 <= b.x) && (b.x <= windowW+100) {
-			s.bullets[n] = *b
-			n++
+			return dead
 		}
 	}
-	s.bullets = s.bullets[:n]
 	// update fading numbers
-	n = 0
+	n := 0
 	for i := range s.numbers {
 		num := &s.numbers[i]
 		num.life -= 0.02
@@ -227,85 +330,74 @@ func (s *playingState) update(window draw.Window) state {
 		}
 	}
 	s.numbers = s.numbers[:n]
-	// update zombies
-	if !dying(s.torso) {
-		s.nextZombie--
-		if s.nextZombie <= 0 {
-			s.newZombie()
-		}
-		for i := range s.zombies {
-			z := &s.zombies[i]
-			if z.facingLeft {
-				z.x -= 2
-			} else {
-				z.x += 2
-			}
-			const hitDist = 40
-			if abs((s.playerX+playerW/2)-(z.x+zombieW/2)) < hitDist {
-				s.torso = realizing
-				s.torsoTime = frames(time.Second)
-			}
-			const zombieFrameCount = 4
-			z.nextFrame--
-			if z.nextFrame <= 0 {
-				z.nextFrame = frames(250 * time.Millisecond)
-				z.frame = (z.frame + 1) % zombieFrameCount
-			}
+	// update blood and gore
+	n = 0
+	for i := range s.blood {
+		b := &s.blood[i]
+		b.x += b.vx
+		b.y += b.vy
+		b.rotation += b.dRotation
+		b.vy += 0.5
+		if b.y < windowH {
+			s.blood[n] = *b
+			n++
 		}
 	}
-	// update blood and gore
-	{
-		n := 0
-		for i := range s.blood {
-			b := &s.blood[i]
-			b.x += b.vx
-			b.y += b.vy
-			b.rotation += b.dRotation
-			b.vy += 0.5
-			if b.y < windowH {
-				s.blood[n] = *b
-				n++
-			}
+	s.blood = s.blood[:n]
+	return playing
+}
+
+// handle shows what happened in the world, keeps the review schedule and tells
+// the opponent in a LAN race about kills and deaths.
+func (s *playingState) handle(e game.Event) {
+	switch e := e.(type) {
+	case game.AnswerSubmitted:
+		color := draw.Green
+		if !e.Correct {
+			color = draw.Red
 		}
-		s.blood = s.blood[:n]
-	}
-	// animations
-	if s.torsoTime > 0 {
-		s.torsoTime--
-		if s.torsoTime == 0 {
-			switch s.torso {
-			case idle:
-				// nothing to do in this case
-			case shooting:
-				s.torso = waitingToReload
-				s.torsoTime = frames(200 * time.Millisecond)
-			case reloading:
-				s.torso = idle
-			case waitingToReload:
-				s.torso = reloading
-				s.torsoTime = frames(250 * time.Millisecond)
-				window.PlaySoundFile("reload.wav")
-			case realizing:
-				s.torso = aimingAtHead
-				s.torsoTime = frames(time.Second)
-				window.PlaySoundFile("uh oh.wav")
-			case aimingAtHead:
-				s.torso = bleeding
-				window.PlaySoundFile("shot.wav")
-				x, y := s.playerNeck()
-				s.sprayBlood(x, y, 100, 200)
-				s.torsoTime = frames(50 * time.Millisecond)
-				s.leaveStateTime = frames(3 * time.Second)
-			case bleeding:
-				// nothing to do in this case
-				s.torsoTime = frames(50 * time.Millisecond)
-				x, y := s.playerNeck()
-				s.sprayBlood(x, y, 5, 10)
-			}
+		s.addFadingText(e.Player, e.Answer, 100, color)
+		if s.review != nil && e.Assignment.Question != "" {
+			s.review.record(e.Assignment, e.Correct, e.AnswerTime)
 		}
+	case game.ComboChanged:
+		if e.Multiplier > 1 {
+			s.addFadingText(e.Player, fmt.Sprintf("COMBO x%d", e.Multiplier), 170, draw.Yellow)
+		} else {
+			s.addFadingText(e.Player, "COMBO LOST", 170, draw.Gray)
+		}
+	case game.TimePenalty:
+		s.addFadingText(e.Player, fmt.Sprintf("-%ds", int(e.Time/time.Second)), 240, draw.Red)
+	case game.ZombieKilled:
+		s.sprayBlood(e.Zombie.X+game.ZombieW/2, e.Zombie.Y+game.ZombieH/2, 10, 30)
+		if s.settings.versus != nil {
+			s.settings.versus.send("kill")
+		}
+	case game.PlayerHit:
+		if s.settings.versus != nil && s.versusOver == "" {
+			s.settings.versus.send("dead")
+			s.versusOver = "You were eaten first!"
+		}
+	case game.PlayerDied:
+		x, y := s.world.Players[e.Player].Neck()
+		s.sprayBlood(x, y, 100, 200)
+		if s.world.AllDying() {
+			s.leaveStateTime = game.Ticks(3 * time.Second)
+		}
+	case game.PlayerBled:
+		x, y := s.world.Players[e.Player].Neck()
+		s.sprayBlood(x, y, 5, 10)
 	}
+}
 
-	// render
+// render draws the world. alpha is how far the time is between the last step
+// and the next one, from 0 to 1, things that move are drawn in between.
+func (s *playingState) render(window draw.Window, alpha float32) {
+	lerp := func(prev, cur int) int {
+		return prev + round(alpha*float32(cur-prev))
+	}
+	w := s.world
+	s.sounds.play(window)
 	// background
 	{
 		const h = 3
@@ -325,49 +417,72 @@ func (s *playingState) update(window draw.Window) state {
 			window.FillRect(0, y, windowW, h, color)
 		}
 	}
-	// player
-	hero := "hero "
-	if s.torso == reloading {
-		hero += "reload "
-	}
-	if s.torso == shooting {
-		hero += "shoot "
-	}
-	if s.torso == aimingAtHead {
-		hero += "aiming at head "
-	}
-	if s.torso == bleeding {
-		hero += "bleeding head "
-	}
-	dir := "right"
-	if s.playerFacingLeft {
-		dir = "left"
-	}
-	hero += dir
-	hero += ".png"
-	window.DrawImageFile(hero, s.playerX, s.playerY)
-	if s.shootBan > 0 {
-		window.DrawImageFile("hero eye blink "+dir+".png", s.playerX, s.playerY)
+	// power-ups lying on the ground, they blink before they disappear
+	for _, p := range w.Pickups {
+		blinkOn := (p.Life/game.Ticks(100*time.Millisecond))%2 == 0
+		if p.Life > game.Ticks(game.PowerUpBlinkTime) || blinkOn {
+			window.DrawImageFile(powerUpImages[p.Kind], p.X, p.Y)
+		}
 	}
-	if walking {
-		img := fmt.Sprintf("hero legs walk %s %d.png", dir, s.playerWalkFrame)
-		window.DrawImageFile(img, s.playerX, s.playerY)
-	} else {
-		window.DrawImageFile("hero legs stand "+dir+".png", s.playerX, s.playerY)
+	// players
+	for i := range w.Players {
+		p := &w.Players[i]
+		x := lerp(p.PrevX, p.X)
+		hero := "hero "
+		if p.Torso == game.Reloading {
+			hero += "reload "
+		}
+		if p.Torso == game.Shooting {
+			hero += "shoot "
+		}
+		if p.Torso == game.AimingAtHead {
+			hero += "aiming at head "
+		}
+		if p.Torso == game.Bleeding {
+			hero += "bleeding head "
+		}
+		dir := "right"
+		if p.FacingLeft {
+			dir = "left"
+		}
+		hero += dir
+		hero += ".png"
+		window.DrawImageFile(hero, x, p.Y)
+		if p.ShootBan > 0 {
+			window.DrawImageFile("hero eye blink "+dir+".png", x, p.Y)
+		}
+		if p.Walking {
+			img := fmt.Sprintf("hero legs walk %s %d.png", dir, p.WalkFrame)
+			window.DrawImageFile(img, x, p.Y)
+		} else {
+			window.DrawImageFile("hero legs stand "+dir+".png", x, p.Y)
+		}
+		if len(w.Players) > 1 {
+			name := fmt.Sprintf("P%d", i+1)
+			const textScale = 1.5
+			textW, _ := window.GetScaledTextSize(name, textScale)
+			window.DrawScaledText(name, x+(game.PlayerW-textW)/2, p.Y+game.PlayerH, textScale, draw.White)
+		}
 	}
 	// zombies
-	for _, z := range s.zombies {
+	for _, z := range w.Zombies {
+		x := lerp(z.PrevX, z.X)
 		dir := "right"
-		if z.facingLeft {
+		if z.FacingLeft {
 			dir = "left"
 		}
 		var img string
-		if dying(s.torso) {
-			img = fmt.Sprintf("zombie %d %s.png", z.kind, dir)
+		if w.AllDying() {
+			img = fmt.Sprintf("zombie %d %s.png", z.Kind, dir)
 		} else {
-			img = fmt.Sprintf("zombie %d %s %d.png", z.kind, dir, z.frame)
+			img = fmt.Sprintf("zombie %d %s %d.png", z.Kind, dir, z.Frame)
+		}
+		window.DrawImageFile(img, x, z.Y)
+		if s.settings.mode == game.ZombieProblems && !z.Targeted && !w.AllDying() {
+			const mathScale = 1.5
+			textW, textH := mathTextSize(window, z.Assignment.Question, mathScale)
+			drawMathText(window, z.Assignment.Question, x+(game.ZombieW-textW)/2, z.Y-textH-5, mathScale, draw.White)
 		}
-		window.DrawImageFile(img, z.x, z.y)
 	}
 	// blood and gore
 	for i := range s.blood {
@@ -380,78 +495,145 @@ func (s *playingState) update(window draw.Window) state {
 		)
 	}
 	// bullets
-	for _, b := range s.bullets {
+	for _, b := range w.Bullets {
 		img := "bullet left.png"
-		if b.dx > 0 {
+		if b.DX > 0 {
 			img = "bullet right.png"
 		}
-		window.DrawImageFile(img, b.x, b.y)
+		window.DrawImageFile(img, lerp(b.PrevX, b.X), lerp(b.PrevY, b.Y))
+	}
+	// score, the first player is shown on the left, the second on the right
+	for i := range w.Players {
+		s.drawPlayerStats(window, &w.Players[i], i == 1)
+	}
+	// active power-ups, the bar below each icon shows the time left
+	powerUpsY := 10
+	if s.settings.sprint != 0 {
+		text := clock(w.TimeLeft, game.TicksPerSecond)
+		const textScale = 4
+		textW, textH := window.GetScaledTextSize(text, textScale)
+		color := draw.White
+		if w.TimeLeft < game.Ticks(10*time.Second) {
+			color = draw.Red
+		}
+		window.DrawScaledText(text, (windowW-textW)/2, 10, textScale, color)
+		powerUpsY += textH + 10
 	}
-	// score
 	{
-		window.DrawImageFile("dead head.png", 0, 0)
-		text := romanNumeral(s.score)
-		const textScale = 3
-		_, h := window.GetScaledTextSize(text, textScale)
-		window.DrawScaledText(text, deadHeadW, (deadHeadH-h)/2, textScale, draw.Red)
+		active := 0
+		for _, left := range w.PowerUpTime {
+			if left > 0 {
+				active++
+			}
+		}
+		x := (windowW - active*(game.PowerUpW+10)) / 2
+		for kind, left := range w.PowerUpTime {
+			if left > 0 {
+				window.DrawImageFile(powerUpImages[kind], x, powerUpsY)
+				total := game.Ticks(game.PowerUpDurations[kind])
+				window.FillRect(x, powerUpsY+game.PowerUpH+4, game.PowerUpW*left/total, 5, draw.White)
+				x += game.PowerUpW + 10
+			}
+		}
 	}
 	// fading numbers from the past
 	for _, num := range s.numbers {
 		scale := 3 + 6*(1-num.life)
 		color := num.color
 		color.A = num.life
-		w, h := window.GetScaledTextSize(num.text, scale)
-		window.DrawScaledText(num.text, (windowW-w)/2, 100-h/2, scale, color)
-	}
-	// assigment
-	const mathScale = 2
-	w, h := window.GetScaledTextSize(s.assignment.question, mathScale)
-	window.DrawScaledText(
-		s.assignment.question,
-		s.playerX+(playerW-w)/2,
-		s.playerY-2*h,
-		mathScale,
-		draw.White,
-	)
-
-	return playing
-}
-
-func (s *playingState) shoot(window draw.Window) {
-	window.PlaySoundFile("shot.wav")
-	const bulletSpeed = 30
-	var b bullet
-	b.y = s.playerY + bulletShootOffsetY
-	if s.playerFacingLeft {
-		b.x = s.playerX
-		b.dx = -bulletSpeed
-	} else {
-		b.x = s.playerX + playerW - bulletW
-		b.dx = bulletSpeed
-	}
-	s.bullets = append(s.bullets, b)
-	oldAssignment := s.assignment
-	for s.assignment == oldAssignment {
-		s.assignment = s.generator.generate(rand.Int)
+		textW, textH := window.GetScaledTextSize(num.text, scale)
+		window.DrawScaledText(num.text, num.x-textW/2, num.y-textH/2, scale, color)
+	}
+	s.achievements.drawToast(window, 150)
+	// result of the LAN race or the end of the sprint
+	if result := s.versusOver; result != "" && !w.AllDying() || w.TimeUp() {
+		if w.TimeUp() {
+			result = "Time's up!"
+		}
+		const textScale = 4
+		textW, textH := window.GetScaledTextSize(result, textScale)
+		window.DrawScaledText(result, (windowW-textW)/2, (windowH-textH)/2, textScale, draw.Yellow)
+	}
+	// assigments
+	for i := range w.Players {
+		p := &w.Players[i]
+		if s.settings.mode == game.ZombieProblems && p.Ammo > 0 {
+			// the problems are above the zombies' heads
+			continue
+		}
+		if s.settings.mode == game.MultipleChoice && !game.Dying(p.Torso) {
+			drawChoices(window, p)
+		}
+		question, color := p.Assignment.Question, draw.White
+		if p.Ammo == 0 {
+			question, color = game.Prompt(p.ReloadAssignment, p.ReloadInput.Text), draw.Yellow
+		}
+		// long questions, like fractions, are made smaller and are kept
+		// inside the window
+		var mathScale float32 = 2
+		textW, textH := mathTextSize(window, question, mathScale)
+		if textW > maxQuestionW {
+			mathScale = 1.5
+			textW, textH = mathTextSize(window, question, mathScale)
+		}
+		x := p.X + (game.PlayerW-textW)/2
+		if x < 5 {
+			x = 5
+		}
+		if x+textW > windowW-5 {
+			x = windowW - 5 - textW
+		}
+		drawMathText(window, question, x, p.Y-2*textH, mathScale, color)
 	}
-	s.torso = shooting
-	s.torsoTime = frames(100 * time.Millisecond)
 }
 
-func (s *playingState) killZombie(i int) {
-	// spray blood
-	z := s.zombies[i]
-	cx, cy := z.x+zombieW/2, z.y+zombieH/2
-	s.sprayBlood(cx, cy, 10, 30)
-
-	// remove zombie from list
-	copy(s.zombies[i:], s.zombies[i+1:])
-	s.zombies = s.zombies[:len(s.zombies)-1]
-	s.score++
-	min, max := s.zombieSpawnDelay.minFrames, s.zombieSpawnDelay.maxFrames
-	s.zombieSpawnDelay.minFrames = min * zombieSpawnReduction
-	if s.score%2 == 1 {
-		s.zombieSpawnDelay.maxFrames = max * zombieSpawnReduction
+// drawPlayerStats draws the kills, points and ammo in the top left corner or,
+// if right is true, in the top right corner.
+func (s *playingState) drawPlayerStats(window draw.Window, p *game.Player, right bool) {
+	const textScale = 3
+	kills := mathgen.RomanNumeral(p.Kills)
+	killsW, killsH := window.GetScaledTextSize(kills, textScale)
+	points := fmt.Sprintf("%d", p.Score)
+	if m := p.Multiplier(); m > 1 {
+		points += fmt.Sprintf(" x%d", m)
+	}
+	const pointsScale = 2
+	pointsW, pointsH := window.GetScaledTextSize(points, pointsScale)
+	x := 20
+	if right {
+		x = windowW - 20 - game.BulletW
+		window.DrawImageFile("dead head.png", windowW-deadHeadW, 0)
+		window.DrawScaledText(kills, windowW-deadHeadW-killsW, (deadHeadH-killsH)/2, textScale, draw.Red)
+		window.DrawScaledText(points, windowW-20-pointsW, deadHeadH, pointsScale, draw.White)
+	} else {
+		window.DrawImageFile("dead head.png", 0, 0)
+		window.DrawScaledText(kills, deadHeadW, (deadHeadH-killsH)/2, textScale, draw.Red)
+		window.DrawScaledText(points, 20, deadHeadH, pointsScale, draw.White)
+	}
+	y := deadHeadH + pointsH + 10
+	if p.Ammo == 0 {
+		const reloadScale = 2
+		text := "RELOAD!"
+		w, _ := window.GetScaledTextSize(text, reloadScale)
+		if right {
+			x = windowW - 20 - w
+		}
+		window.DrawScaledText(text, x, y, reloadScale, draw.Red)
+	}
+	for i := 0; i < p.Ammo; i++ {
+		window.DrawImageFile("bullet right.png", x, y+i*(game.BulletH+6))
+	}
+	if p.Tokens > 0 {
+		y += game.MagazineSize*(game.BulletH+6) + 10
+		text := fmt.Sprintf("%dx", p.Tokens)
+		const tokenScale = 2
+		w, h := window.GetScaledTextSize(text, tokenScale)
+		x, textX := 20+w, 20
+		if right {
+			x, textX = windowW-20-game.PowerUpW, windowW-20-game.PowerUpW-w
+		}
+		window.DrawImageFile(powerUpImages[game.FreeAnswer], x, y)
+		window.DrawScaledText(text, textX, y+(game.PowerUpH-h)/2, tokenScale, draw.White)
 	}
 }
 
@@ -469,58 +651,56 @@ func (s *playingState) sprayBlood(x, y, min, max int) {
 	}
 }
 
-func (s *playingState) addFadingNumber(n int, color draw.Color) {
+// addFadingText adds a text that grows and fades out at height y. It is
+// centered on the screen or, in co-op mode, above the given player.
+func (s *playingState) addFadingText(player int, text string, y int, color draw.Color) {
+	x := windowW / 2
+	if len(s.world.Players) > 1 {
+		x = s.world.Players[player].X + game.PlayerW/2
+	}
 	s.numbers = append(s.numbers, fadingNumber{
-		text:  fmt.Sprintf("%d", n),
+		text:  text,
+		x:     x,
+		y:     y,
 		life:  1.0,
 		color: color,
 	})
 }
 
-func (s *playingState) newZombie() {
-	var z zombie
-	z.facingLeft = rand.Intn(2) == 0
-	z.y = s.playerY + playerH - zombieH - 10 + rand.Intn(30)
-	if z.facingLeft {
-		z.x = windowW
-	} else {
-		z.x = -zombieW
-	}
-	const zombieKindCount = 3
-	z.kind = rand.Intn(zombieKindCount)
-	s.zombies = append(s.zombies, z)
-	min := round(s.zombieSpawnDelay.minFrames)
-	max := round(s.zombieSpawnDelay.maxFrames)
-	s.nextZombie = min + rand.Intn(max-min)
-}
-
-func (s *playingState) playerNeck() (x, y int) {
-	dx := -6
-	if s.playerFacingLeft {
-		dx = -dx
+// receiveFromOpponent handles the messages of the other player in a LAN race.
+func (s *playingState) receiveFromOpponent() {
+	for {
+		msg, open := s.settings.versus.receive()
+		if !open {
+			if s.versusOver == "" {
+				s.versusOver = "Your opponent left."
+				s.leaveStateTime = game.Ticks(3 * time.Second)
+			}
+			return
+		}
+		switch msg {
+		case "":
+			return
+		case "kill":
+			if !s.world.AllDying() {
+				s.world.SpawnZombie()
+			}
+		case "dead":
+			if s.versusOver == "" {
+				s.versusOver = "You won the race!"
+				s.leaveStateTime = game.Ticks(3 * time.Second)
+			}
+		}
 	}
-	return s.playerX + playerW/2 + dx, s.playerY + playerHeadH
 }
 
 type fadingNumber struct {
 	text  string
+	x, y  int
 	life  float32
 	color draw.Color
 }
 
-type bullet struct {
-	x, y int
-	dx   int
-}
-
-type zombie struct {
-	x, y       int
-	facingLeft bool
-	frame      int
-	nextFrame  int
-	kind       int
-}
-
 type bloodParticle struct {
 	x, y      float32
 	vx, vy    float32
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gonutz/ld41/game"
+	"github.com/gonutz/ld41/mathgen"
+	"github.com/gonutz/prototype/draw"
+)
+
+// TestPauseMenuSavesTheGame opens the pause menu, picks Save and Quit and
+// expects the main menu to offer continuing the game.
+func TestPauseMenuSavesTheGame(t *testing.T) {
+	window := newGoldenWindow(t)
+	startGame(7)
+	run(window, playing, 10, nil)
+	run(window, playing, 1, func(int) {
+		window.Press(draw.KeyEscape)
+	})
+	if !playing.paused {
+		t.Fatal("Escape did not pause the game")
+	}
+	run(window, playing, 1, nil)
+	if !playing.paused {
+		t.Fatal("the game resumed without a key press")
+	}
+	run(window, playing, 1, func(int) {
+		window.Press(draw.KeyDown)
+	})
+	if playing.pauseItem != pauseSaveAndQuit {
+		t.Fatalf("Down selected pause item %d instead of Save and Quit", playing.pauseItem)
+	}
+	s := run(window, playing, 1, func(int) {
+		window.Press(draw.KeyEnter)
+	})
+	if s != menu {
+		t.Fatal("Save and Quit did not go back to the menu")
+	}
+	if !menu.canResume || menu.hotItem != menuContinue {
+		t.Error("the menu does not offer to continue the saved game")
+	}
+}
+
+func TestEscapeResumesThePausedGame(t *testing.T) {
+	window := newGoldenWindow(t)
+	startGame(7)
+	run(window, playing, 1, func(int) {
+		window.Press(draw.KeyEscape)
+	})
+	run(window, playing, 1, func(int) {
+		window.Press(draw.KeyEscape)
+	})
+	if playing.paused {
+		t.Error("the second Escape did not resume the game")
+	}
+}
+
+// TestClickPicksTheSignOfOnePlayer lets the co-op heroes stand at the same spot
+// so their signs overlap.
+func TestClickPicksTheSignOfOnePlayer(t *testing.T) {
+	window := newGoldenWindow(t)
+	playing.settings = gameSettings{
+		players:    2,
+		seed:       7,
+		difficulty: mathgen.Normal,
+		mode:       game.MultipleChoice,
+	}
+	playing.enter(menu)
+	playing.world.Players[1].X = playing.world.Players[0].X
+	r := choiceRects(window, &playing.world.Players[0])[1]
+	window.Frame()
+	window.Click(r.x+r.w/2, r.y+r.h/2, draw.LeftButton)
+	playing.readInput(window)
+	if got := playing.input.Players[0].ClickedChoice; got != 2 {
+		t.Errorf("player 1 clicked sign %d instead of 2", got)
+	}
+	if got := playing.input.Players[1].ClickedChoice; got != 0 {
+		t.Errorf("player 2 also clicked sign %d", got)
+	}
+}
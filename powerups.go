@@ -0,0 +1,11 @@
+package main
+
+import "github.com/gonutz/ld41/game"
+
+// powerUpImages show the power-ups lying on the ground and the active ones.
+var powerUpImages = [game.PowerUpKindCount]string{
+	game.PiercingBullets: "power-up piercing.png",
+	game.ShotgunSpread:   "power-up shotgun.png",
+	game.SlowMotion:      "power-up slow motion.png",
+	game.FreeAnswer:      "power-up free answer.png",
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/gonutz/ld41/game"
+	"github.com/gonutz/ld41/mathgen"
+	"github.com/gonutz/prototype/draw"
+)
+
+// practiceState is a drill without zombies. The player picks a family of math
+// facts, answers a fixed number of problems and sees all mistakes at the end.
+type practiceState struct {
+	phase      practicePhase
+	hotRow     int
+	opIndex    int // index into practiceOps
+	limit      int // index into practiceLimits or practiceTables
+	count      int // index into practiceCounts
+	generator  mathgen.Generator
+	assignment mathgen.Assignment
+	input      game.AnswerInput
+	solved     int // number of problems answered so far
+	correct    int
+	mistakes   []practiceMistake
+	flash      int // time left to show the last answer's color
+	flashColor draw.Color
+}
+
+type practicePhase int
+
+const (
+	choosingFacts practicePhase = iota
+	drilling
+	showingResults
+)
+
+type practiceMistake struct {
+	question string
+	typed    string
+	answer   mathgen.Number
+}
+
+var (
+	practiceOps    = []mathgen.Op{mathgen.Add, mathgen.Subtract, mathgen.Multiply, mathgen.Divide}
+	practiceLimits = []int{10, 20, 50, 100} // for add and subtract
+	practiceTables = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	practiceCounts = []int{10, 20, 30}
+)
+
+const (
+	practiceOpRow = iota
+	practiceRangeRow
+	practiceCountRow
+	practiceStartRow
+	practiceRowCount
+)
+
+func (s *practiceState) enter(state) {
+	s.phase = choosingFacts
+	s.hotRow = practiceStartRow
+}
+
+func (*practiceState) leave() {}
+
+func (s *practiceState) update(window draw.Window) state {
+	switch s.phase {
+	case choosingFacts:
+		return s.chooseFacts(window)
+	case drilling:
+		return s.drill(window)
+	default:
+		return s.showResults(window)
+	}
+}
+
+func (s *practiceState) op() mathgen.Op {
+	return practiceOps[s.opIndex]
+}
+
+func (s *practiceState) ranges() []int {
+	if s.op() == mathgen.Multiply || s.op() == mathgen.Divide {
+		return practiceTables
+	}
+	return practiceLimits
+}
+
+func (s *practiceState) rangeText() string {
+	n := s.ranges()[s.limit]
+	switch s.op() {
+	case mathgen.Add:
+		return fmt.Sprintf("addition within %d", n)
+	case mathgen.Subtract:
+		return fmt.Sprintf("subtraction within %d", n)
+	case mathgen.Multiply:
+		return fmt.Sprintf("the %d times table", n)
+	default:
+		return fmt.Sprintf("dividing by %d", n)
+	}
+}
+
+func (s *practiceState) chooseFacts(window draw.Window) state {
+	if window.WasKeyPressed(draw.KeyEscape) {
+		return menu
+	}
+	oldRow := s.hotRow
+	if window.WasKeyPressed(draw.KeyDown) {
+		s.hotRow = (s.hotRow + 1) % practiceRowCount
+	}
+	if window.WasKeyPressed(draw.KeyUp) {
+		s.hotRow = (s.hotRow + practiceRowCount - 1) % practiceRowCount
+	}
+	delta := 0
+	if window.WasKeyPressed(draw.KeyRight) {
+		delta = 1
+	}
+	if window.WasKeyPressed(draw.KeyLeft) {
+		delta = -1
+	}
+	if delta != 0 {
+		switch s.hotRow {
+		case practiceOpRow:
+			s.opIndex = (s.opIndex + len(practiceOps) + delta) % len(practiceOps)
+			s.limit = 0
+		case practiceRangeRow:
+			s.limit = (s.limit + len(s.ranges()) + delta) % len(s.ranges())
+		case practiceCountRow:
+			s.count = (s.count + len(practiceCounts) + delta) % len(practiceCounts)
+		}
+	}
+	if s.hotRow != oldRow || delta != 0 {
+		window.PlaySoundFile("menu beep.wav")
+	}
+	if window.WasKeyPressed(draw.KeyEnter) || window.WasKeyPressed(draw.KeyNumEnter) {
+		s.start()
+	}
+	// render
+	rows := []string{
+		"Operation " + s.op().String(),
+		s.rangeText(),
+		fmt.Sprintf("%d problems", practiceCounts[s.count]),
+		"Start",
+	}
+	const textScale = 3
+	_, lineH := window.GetScaledTextSize("A", textScale)
+	for i, row := range rows {
+		if i != practiceStartRow {
+			row = "< " + row + " >"
+		}
+		w, _ := window.GetScaledTextSize(row, textScale)
+		x := (windowW - w) / 2
+		y := (windowH-lineH*len(rows))/2 + i*lineH
+		if i == s.hotRow {
+			window.FillRect(x-20, y, w+40, lineH, draw.DarkRed)
+		}
+		window.DrawScaledText(row, x, y, textScale, draw.White)
+	}
+	return practice
+}
+
+func (s *practiceState) start() {
+	s.phase = drilling
+	s.generator = mathgen.Generator{Ops: []mathgen.Op{s.op()}}
+	if s.op() == mathgen.Multiply || s.op() == mathgen.Divide {
+		s.generator.Factor = s.ranges()[s.limit]
+	} else {
+		s.generator.Max = s.ranges()[s.limit]
+	}
+	s.assignment = s.generator.Generate(rand.Int)
+	s.input.Clear()
+	s.solved = 0
+	s.correct = 0
+	s.mistakes = nil
+	s.flash = 0
+}
+
+func (s *practiceState) drill(window draw.Window) state {
+	if window.WasKeyPressed(draw.KeyEscape) {
+		s.phase = showingResults
+		return practice
+	}
+	var (
+		typed             string
+		complete, correct bool
+	)
+	if char, ok := allKeys.pressedAnswerKey(window); ok {
+		typed, complete, correct = s.input.Add(char, s.assignment.Answer)
+	} else if anyKeyPressed(window, allKeys.pickChoice) {
+		typed, complete, correct = s.input.Submit(s.assignment.Answer)
+	}
+	if complete {
+		s.solved++
+		s.flash = frames(300 * time.Millisecond)
+		if correct {
+			s.correct++
+			s.flashColor = draw.DarkGreen
+			window.PlaySoundFile("menu beep.wav")
+		} else {
+			s.mistakes = append(s.mistakes, practiceMistake{
+				question: s.assignment.Question,
+				typed:    typed,
+				answer:   s.assignment.Answer,
+			})
+			s.flashColor = draw.DarkRed
+			window.PlaySoundFile("miss shot.wav")
+		}
+		if s.solved == practiceCounts[s.count] {
+			s.phase = showingResults
+		}
+		oldAssignment := s.assignment
+		for s.assignment == oldAssignment {
+			s.assignment = s.generator.Generate(rand.Int)
+		}
+	}
+	// render
+	if s.flash > 0 {
+		s.flash--
+		window.FillRect(0, 0, windowW, windowH, s.flashColor)
+	}
+	progress := fmt.Sprintf("%s, problem %d of %d", s.rangeText(), s.solved+1, practiceCounts[s.count])
+	const progressScale = 2
+	w, _ := window.GetScaledTextSize(progress, progressScale)
+	window.DrawScaledText(progress, (windowW-w)/2, 50, progressScale, draw.Gray)
+	text := game.Prompt(s.assignment, s.input.Text)
+	const textScale = 6
+	w, h := mathTextSize(window, text, textScale)
+	drawMathText(window, text, (windowW-w)/2, (windowH-h)/2, textScale, draw.White)
+	return practice
+}
+
+func (s *practiceState) showResults(window draw.Window) state {
+	if window.WasKeyPressed(draw.KeyEscape) {
+		return menu
+	}
+	if window.WasKeyPressed(draw.KeyEnter) || window.WasKeyPressed(draw.KeyNumEnter) {
+		s.phase = choosingFacts
+	}
+	// render
+	title := fmt.Sprintf("%d of %d correct", s.correct, s.solved)
+	if s.solved > 0 && s.correct == s.solved {
+		title += " - perfect!"
+	}
+	const titleScale = 3
+	w, h := window.GetScaledTextSize(title, titleScale)
+	window.DrawScaledText(title, (windowW-w)/2, 30, titleScale, draw.White)
+	// the mistakes are listed in two columns
+	const (
+		lineScale      = 1.5
+		linesPerColumn = 15
+	)
+	for i, m := range s.mistakes {
+		text := fmt.Sprintf("%s = %s, not %s", m.question, m.answer, m.typed)
+		x := windowW/4 - 100 + (i/linesPerColumn)*windowW/2
+		_, lineH := mathTextSize(window, text, lineScale)
+		y := 30 + h + 30 + (i%linesPerColumn)*lineH
+		drawMathText(window, text, x, y, lineScale, draw.LightRed)
+	}
+	const msg = "Press ENTER to practice again"
+	w, h = window.GetScaledTextSize(msg, 2)
+	window.DrawScaledText(msg, (windowW-w)/2, windowH-h-20, 2, draw.White)
+	return practice
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gonutz/prototype/draw"
+)
+
+// A profile is the name of whoever is playing. Progress that belongs to a
+// person, like the math facts to review, is stored per profile.
+const (
+	profileFile    = "brainless_jogging_profile"
+	defaultProfile = "Player"
+)
+
+var currentProfile = defaultProfile
+
+func loadProfile() string {
+	name := strings.TrimSpace(loadText(profileFile))
+	if name == "" {
+		return defaultProfile
+	}
+	return name
+}
+
+func saveProfile(name string) {
+	saveText(profileFile, name)
+}
+
+// profileFileName returns a storage name that is unique for the profile and
+// safe to use as a file name. Other characters are replaced by _ and a hash of
+// the profile is appended in that case, so "a/b" and "a?b" are stored apart.
+func profileFileName(prefix, profile string) string {
+	replaced := false
+	safe := strings.Map(func(r rune) rune {
+		if 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		replaced = true
+		return '_'
+	}, profile)
+	if replaced {
+		h := fnv.New32a()
+		h.Write([]byte(profile))
+		// a . never appears in the safe part, names without replacements
+		// cannot end up here
+		safe += fmt.Sprintf(".%08x", h.Sum32())
+	}
+	return prefix + "_" + safe
+}
+
+// profileState lets the player type the profile name.
+type profileState struct {
+	name          string
+	cursorBlink   int
+	cursorVisible bool
+}
+
+func (s *profileState) enter(state) {
+	s.name = currentProfile
+	s.cursorBlink = frames(cursorBlinkTime)
+	s.cursorVisible = true
+}
+
+func (*profileState) leave() {}
+
+func (s *profileState) update(window draw.Window) state {
+	if window.WasKeyPressed(draw.KeyEscape) {
+		return menu
+	}
+	if window.WasKeyPressed(draw.KeyEnter) || window.WasKeyPressed(draw.KeyNumEnter) {
+		if name := strings.TrimSpace(s.name); name != "" {
+			currentProfile = name
+			saveProfile(name)
+		}
+		return menu
+	}
+	for _, r := range window.Characters() {
+		if len(s.name) < maxNameLen && (32 <= r) && (r <= 126) {
+			s.name += string(r)
+		}
+		s.cursorVisible = true
+		s.cursorBlink = frames(cursorBlinkTime)
+	}
+	if window.WasKeyPressed(draw.KeyBackspace) && s.name != "" {
+		_, size := utf8.DecodeLastRuneInString(s.name)
+		s.name = s.name[:len(s.name)-size]
+		s.cursorVisible = true
+		s.cursorBlink = frames(cursorBlinkTime)
+	}
+	s.cursorBlink--
+	if s.cursorBlink < 0 {
+		s.cursorVisible = !s.cursorVisible
+		s.cursorBlink = frames(cursorBlinkTime)
+	}
+	// render
+	const (
+		title     = "Who is playing?"
+		textScale = 3
+	)
+	w, h := window.GetScaledTextSize(title, textScale)
+	window.DrawScaledText(title, (windowW-w)/2, windowH/2-2*h, textScale, draw.White)
+	name := s.name
+	if s.cursorVisible {
+		name += "|"
+	}
+	w, _ = window.GetScaledTextSize(s.name+"|", textScale)
+	window.DrawScaledText(name, (windowW-w)/2, windowH/2, textScale, draw.Yellow)
+	return profile
+}
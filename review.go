@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gonutz/ld41/game"
+	"github.com/gonutz/ld41/mathgen"
+)
+
+// The review schedule remembers the math facts that a player got wrong or
+// answered slowly and brings them back in later sessions, like flash card
+// software does. Facts are sorted into boxes. A missed fact goes into box 0 and
+// is due again in the next session. Each time it is answered correctly and
+// fast, it moves up one box and the wait until it is due doubles. Facts that
+// leave the last box are learned and removed from the schedule.
+const (
+	reviewFilePrefix = "brainless_jogging_review"
+	reviewBoxes      = 5
+	slowAnswerTime   = 5 * time.Second
+	// reviewShare is the percentage of assignments that are taken from the
+	// facts due for review instead of being random.
+	reviewShare = 30
+)
+
+type reviewSchedule struct {
+	profile string
+	session int // counts up each time a game is started
+	facts   []reviewFact
+}
+
+type reviewFact struct {
+	mathgen.Assignment
+	box int
+	due int // session in which the fact is asked again
+}
+
+func loadReviews(profile string) *reviewSchedule {
+	r := &reviewSchedule{profile: profile}
+	for _, line := range strings.Split(loadText(r.fileName()), "\n") {
+		if n, err := fmt.Sscanf(line, "session %d", &r.session); n == 1 && err == nil {
+			continue
+		}
+		// box due answer op question
+		cols := strings.SplitN(line, " ", 5)
+		if len(cols) != 5 {
+			continue
+		}
+		var f reviewFact
+		var op int
+		ints := map[int]*int{0: &f.box, 1: &f.due, 3: &op}
+		valid := true
+		for i, n := range ints {
+			var err error
+			*n, err = strconv.Atoi(cols[i])
+			valid = valid && err == nil
+		}
+		answer, err := mathgen.ParseNumber(cols[2])
+		f.Answer = answer
+		valid = valid && err == nil
+		f.Op = mathgen.Op(op)
+		f.Question = cols[4]
+		if valid && 0 <= f.Op && f.Op < mathgen.Custom {
+			r.facts = append(r.facts, f)
+		}
+	}
+	return r
+}
+
+func (r *reviewSchedule) fileName() string {
+	return profileFileName(reviewFilePrefix, r.profile)
+}
+
+func (r *reviewSchedule) save() {
+	text := fmt.Sprintf("session %d\n", r.session)
+	for _, f := range r.facts {
+		text += fmt.Sprintf("%d %d %s %d %s\n", f.box, f.due, f.Answer, f.Op, f.Question)
+	}
+	saveText(r.fileName(), text)
+}
+
+func (r *reviewSchedule) startSession() {
+	r.session++
+}
+
+// record updates the schedule after the player answered a. answerTime is the
+// number of game ticks it took.
+func (r *reviewSchedule) record(a mathgen.Assignment, correct bool, answerTime int) {
+	index := -1
+	for i := range r.facts {
+		if r.facts[i].Assignment == a {
+			index = i
+		}
+	}
+	if !correct || answerTime > game.Ticks(slowAnswerTime) {
+		if index == -1 {
+			r.facts = append(r.facts, reviewFact{Assignment: a})
+			index = len(r.facts) - 1
+		}
+		r.facts[index].box = 0
+		r.facts[index].due = r.session + 1
+		return
+	}
+	if index == -1 || r.facts[index].due > r.session {
+		// only due facts move up, answering it again in the same session
+		// does not count
+		return
+	}
+	f := &r.facts[index]
+	f.box++
+	if f.box > reviewBoxes {
+		copy(r.facts[index:], r.facts[index+1:])
+		r.facts = r.facts[:len(r.facts)-1]
+		return
+	}
+	f.due = r.session + 1<<f.box
+}
+
+// Due returns the facts that are due in this session and that the generator
+// could have created itself.
+func (r *reviewSchedule) Due(g mathgen.Generator) []mathgen.Assignment {
+	var list []mathgen.Assignment
+	for _, f := range r.facts {
+		if f.due <= r.session && g.CanGenerate(f.Assignment) {
+			list = append(list, f.Assignment)
+		}
+	}
+	return list
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gonutz/ld41/game"
+	"github.com/gonutz/ld41/mathgen"
+)
+
+// A game that is quit from the pause menu is saved so it can be continued from
+// the main menu. Each profile has one saved game, which is deleted once it is
+// continued.
+const saveFilePrefix = "brainless_jogging_save"
+
+// savedRun is the saved game together with the settings that it was started
+// with and what the achievements count per game.
+type savedRun struct {
+	Players    int
+	Seed       int64 // 0 for a random game
+	Daily      string
+	Sprint     time.Duration
+	Pack       string // file of the problem pack, empty for generated math
+	Tag        string
+	Difficulty mathgen.Difficulty
+	Mode       game.Mode
+	CleanKills map[int]int
+	Kills      int
+	World      json.RawMessage
+}
+
+func saveFileName(profile string) string {
+	return profileFileName(saveFilePrefix, profile)
+}
+
+func hasSavedRun(profile string) bool {
+	return loadText(saveFileName(profile)) != ""
+}
+
+func deleteSavedRun(profile string) {
+	saveText(saveFileName(profile), "")
+}
+
+// loadSavedRun reads the profile's saved game. It fails if the problem pack
+// that the game was played with is gone.
+func loadSavedRun(profile string) (*savedRun, gameSettings, error) {
+	var run savedRun
+	if err := json.Unmarshal([]byte(loadText(saveFileName(profile))), &run); err != nil {
+		return nil, gameSettings{}, err
+	}
+	settings := gameSettings{
+		players:    run.Players,
+		seed:       run.Seed,
+		daily:      run.Daily,
+		sprint:     run.Sprint,
+		difficulty: run.Difficulty,
+		mode:       run.Mode,
+	}
+	if run.Pack != "" {
+		for _, pack := range loadPacks() {
+			if pack.file == run.Pack && len(pack.errors) == 0 {
+				settings.problems = packChoice{pack: pack, tag: run.Tag}
+			}
+		}
+		if settings.problems.pack == nil {
+			return nil, gameSettings{}, errors.New("the problem pack " + run.Pack + " is gone")
+		}
+	}
+	return &run, settings, nil
+}
+
+// saveRun writes the game so it can be continued later.
+func (s *playingState) saveRun() {
+	world, err := s.world.Save()
+	if err != nil {
+		return
+	}
+	run := savedRun{
+		Players:    s.settings.players,
+		Seed:       s.settings.seed,
+		Daily:      s.settings.daily,
+		Sprint:     s.settings.sprint,
+		Difficulty: s.settings.difficulty,
+		Mode:       s.settings.mode,
+		CleanKills: s.achievements.cleanKills,
+		Kills:      s.achievements.kills,
+		World:      world,
+	}
+	if s.settings.problems.pack != nil {
+		run.Pack = s.settings.problems.pack.file
+		run.Tag = s.settings.problems.tag
+	}
+	data, err := json.Marshal(run)
+	if err != nil {
+		return
+	}
+	saveText(saveFileName(currentProfile), string(data))
+}
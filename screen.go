@@ -0,0 +1,201 @@
+package main
+
+import (
+	"math"
+
+	"github.com/gonutz/prototype/draw"
+)
+
+// The game is laid out for a virtual screen of windowW x windowH pixels. The
+// screen scales it to whatever size the window has, in fullscreen, in the
+// browser or on a 4K monitor, keeping the aspect ratio. The rest of the window
+// is filled with black bars. Mouse positions are mapped back to the virtual
+// screen, so the states never see the real window size.
+type screen struct {
+	draw.Window
+	scale            float32
+	offsetX, offsetY int // of the virtual screen in the window
+	width, height    int // of the window
+}
+
+// fitScreen places the largest virtual screen that fits into the window in its
+// center.
+func fitScreen(window draw.Window) screen {
+	width, height := window.Size()
+	scale := float32(math.Min(float64(width)/windowW, float64(height)/windowH))
+	if scale <= 0 {
+		scale = 1
+	}
+	return screen{
+		Window:  window,
+		scale:   scale,
+		offsetX: (width - round(windowW*scale)) / 2,
+		offsetY: (height - round(windowH*scale)) / 2,
+		width:   width,
+		height:  height,
+	}
+}
+
+// toggleFullscreen switches between the window and fullscreen when ALT+ENTER
+// is pressed.
+func toggleFullscreen(window draw.Window) {
+	if altEnter(window) {
+		window.SetFullscreen(!window.IsFullscreen())
+	}
+}
+
+func altEnter(window draw.Window) bool {
+	return (window.IsKeyDown(draw.KeyLeftAlt) || window.IsKeyDown(draw.KeyRightAlt)) &&
+		(window.WasKeyPressed(draw.KeyEnter) || window.WasKeyPressed(draw.KeyNumEnter))
+}
+
+// drawBars covers everything that was drawn outside of the virtual screen.
+func (s screen) drawBars() {
+	right := s.x(windowW)
+	bottom := s.y(windowH)
+	s.Window.FillRect(0, 0, s.offsetX, s.height, draw.Black)
+	s.Window.FillRect(right, 0, s.width-right, s.height, draw.Black)
+	s.Window.FillRect(0, 0, s.width, s.offsetY, draw.Black)
+	s.Window.FillRect(0, bottom, s.width, s.height-bottom, draw.Black)
+}
+
+// x and y convert virtual coordinates to window coordinates.
+func (s screen) x(x int) int {
+	return s.offsetX + round(float32(x)*s.scale)
+}
+
+func (s screen) y(y int) int {
+	return s.offsetY + round(float32(y)*s.scale)
+}
+
+// rect converts a rectangle by its edges, so rectangles that touch on the
+// virtual screen still touch in the window. Rectangles do not vanish when the
+// window is smaller than the virtual screen.
+func (s screen) rect(x, y, width, height int) (int, int, int, int) {
+	left, top := s.x(x), s.y(y)
+	w, h := s.x(x+width)-left, s.y(y+height)-top
+	if width > 0 && w < 1 {
+		w = 1
+	}
+	if height > 0 && h < 1 {
+		h = 1
+	}
+	return left, top, w, h
+}
+
+// size converts the size of an image. Unlike rect, the size is the same
+// wherever the image is, so moving images do not wobble.
+func (s screen) size(width, height int) (int, int) {
+	return round(float32(width) * s.scale), round(float32(height) * s.scale)
+}
+
+func (s screen) Size() (width, height int) {
+	return windowW, windowH
+}
+
+// WasKeyPressed hides the ENTER of ALT+ENTER from the game, it toggles
+// fullscreen.
+func (s screen) WasKeyPressed(key draw.Key) bool {
+	if (key == draw.KeyEnter || key == draw.KeyNumEnter) && altEnter(s.Window) {
+		return false
+	}
+	return s.Window.WasKeyPressed(key)
+}
+
+func (s screen) MousePosition() (int, int) {
+	x, y := s.Window.MousePosition()
+	return s.virtual(x, y)
+}
+
+func (s screen) Clicks() []draw.MouseClick {
+	clicks := s.Window.Clicks()
+	if len(clicks) == 0 {
+		return clicks
+	}
+	mapped := make([]draw.MouseClick, len(clicks))
+	for i, c := range clicks {
+		mapped[i] = c
+		mapped[i].X, mapped[i].Y = s.virtual(c.X, c.Y)
+	}
+	return mapped
+}
+
+// virtual converts window coordinates to virtual coordinates. It maps the
+// center of the window pixel, so the pixels that x and y convert to are mapped
+// back to where they came from.
+func (s screen) virtual(x, y int) (int, int) {
+	return int(math.Floor((float64(x-s.offsetX) + 0.5) / float64(s.scale))),
+		int(math.Floor((float64(y-s.offsetY) + 0.5) / float64(s.scale)))
+}
+
+func (s screen) DrawPoint(x, y int, color draw.Color) {
+	s.FillRect(x, y, 1, 1, color)
+}
+
+func (s screen) DrawLine(fromX, fromY, toX, toY int, color draw.Color) {
+	s.Window.DrawLine(s.x(fromX), s.y(fromY), s.x(toX), s.y(toY), color)
+}
+
+func (s screen) DrawRect(x, y, width, height int, color draw.Color) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	s.FillRect(x, y, width, 1, color)
+	s.FillRect(x, y, 1, height, color)
+	s.FillRect(x+width-1, y, 1, height, color)
+	s.FillRect(x, y+height-1, width, 1, color)
+}
+
+func (s screen) FillRect(x, y, width, height int, color draw.Color) {
+	x, y, width, height = s.rect(x, y, width, height)
+	s.Window.FillRect(x, y, width, height, color)
+}
+
+func (s screen) DrawEllipse(x, y, width, height int, color draw.Color) {
+	x, y, width, height = s.rect(x, y, width, height)
+	s.Window.DrawEllipse(x, y, width, height, color)
+}
+
+func (s screen) FillEllipse(x, y, width, height int, color draw.Color) {
+	x, y, width, height = s.rect(x, y, width, height)
+	s.Window.FillEllipse(x, y, width, height, color)
+}
+
+func (s screen) DrawImageFile(path string, x, y int) error {
+	return s.DrawImageFileRotated(path, x, y, 0)
+}
+
+func (s screen) DrawImageFileRotated(path string, x, y, degrees int) error {
+	width, height, err := s.Window.ImageSize(path)
+	if err != nil {
+		return err
+	}
+	return s.DrawImageFileTo(path, x, y, width, height, degrees)
+}
+
+func (s screen) DrawImageFileTo(path string, x, y, width, height, degrees int) error {
+	width, height = s.size(width, height)
+	return s.Window.DrawImageFileTo(path, s.x(x), s.y(y), width, height, degrees)
+}
+
+func (s screen) DrawImageFilePart(
+	path string,
+	sourceX, sourceY, sourceWidth, sourceHeight int,
+	destX, destY, destWidth, destHeight int,
+	rotationCWDeg int,
+) error {
+	destWidth, destHeight = s.size(destWidth, destHeight)
+	return s.Window.DrawImageFilePart(path,
+		sourceX, sourceY, sourceWidth, sourceHeight,
+		s.x(destX), s.y(destY), destWidth, destHeight,
+		rotationCWDeg,
+	)
+}
+
+func (s screen) DrawText(text string, x, y int, color draw.Color) {
+	s.DrawScaledText(text, x, y, 1, color)
+}
+
+func (s screen) DrawScaledText(text string, x, y int, scale float32, color draw.Color) {
+	s.Window.DrawScaledText(text, s.x(x), s.y(y), scale*s.scale, color)
+}
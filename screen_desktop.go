@@ -0,0 +1,6 @@
+//go:build !js
+
+package main
+
+// fitCanvas does nothing, the window always has as many pixels as it shows.
+func fitCanvas() {}
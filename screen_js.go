@@ -0,0 +1,24 @@
+//go:build js
+
+package main
+
+import "syscall/js"
+
+// fitCanvas gives the canvas as many pixels as it takes up on the page. A page
+// that sizes the canvas with CSS would otherwise stretch the picture and the
+// mouse positions would not match it.
+func fitCanvas() {
+	canvas := js.Global().Get("document").Call("getElementById", "gameCanvas")
+	if !canvas.Truthy() {
+		return
+	}
+	width, height := canvas.Get("clientWidth").Int(), canvas.Get("clientHeight").Int()
+	if width <= 0 || height <= 0 {
+		return
+	}
+	// setting the size clears the canvas, so only do it if it changed
+	if canvas.Get("width").Int() != width || canvas.Get("height").Int() != height {
+		canvas.Set("width", width)
+		canvas.Set("height", height)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/gonutz/ld41/offscreen"
+	"github.com/gonutz/prototype/draw"
+)
+
+func TestScreenIsLetterboxed(t *testing.T) {
+	// a 1366x768 laptop is relatively taller than the game, it gets bars at
+	// the top and bottom
+	window := offscreen.New(1366, 768)
+	view := fitScreen(window)
+	if view.offsetX != 0 || view.offsetY != 42 {
+		t.Fatalf("the game is at %d,%d instead of 0,42", view.offsetX, view.offsetY)
+	}
+	if w, h := view.Size(); w != windowW || h != windowH {
+		t.Errorf("the game sees a %dx%d window", w, h)
+	}
+	view.FillRect(-100, -100, windowW+200, windowH+200, draw.White)
+	view.drawBars()
+	black := color.RGBA{0, 0, 0, 255}
+	white := color.RGBA{255, 255, 255, 255}
+	for _, p := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{0, 41, black},
+		{0, 42, white},
+		{1365, 724, white},
+		{1365, 725, black},
+	} {
+		if c := window.Image().RGBAAt(p.x, p.y); c != p.want {
+			t.Errorf("pixel %d,%d is %v instead of %v", p.x, p.y, c, p.want)
+		}
+	}
+}
+
+func TestScreenMapsTheMouseBack(t *testing.T) {
+	window := offscreen.New(3840, 2160)
+	view := fitScreen(window)
+	window.Click(view.x(600), view.y(300), draw.LeftButton)
+	if x, y := view.MousePosition(); x != 600 || y != 300 {
+		t.Errorf("the mouse is at %d,%d instead of 600,300", x, y)
+	}
+	if c := view.Clicks()[0]; c.X != 600 || c.Y != 300 {
+		t.Errorf("the click is at %d,%d instead of 600,300", c.X, c.Y)
+	}
+}
+
+func TestAltEnterTogglesFullscreen(t *testing.T) {
+	window := offscreen.New(windowW, windowH)
+	window.Press(draw.KeyLeftAlt, draw.KeyEnter)
+	toggleFullscreen(window)
+	if !window.IsFullscreen() {
+		t.Error("ALT+ENTER did not go fullscreen")
+	}
+	if fitScreen(window).WasKeyPressed(draw.KeyEnter) {
+		t.Error("the game saw the ENTER of ALT+ENTER")
+	}
+}
+
+// TestGoldenScreenSizes draws the scripted game on a laptop and on a small
+// browser canvas.
+func TestGoldenScreenSizes(t *testing.T) {
+	for _, size := range []struct {
+		name          string
+		width, height int
+	}{
+		{"gameplay 1366x768", 1366, 768},
+		{"gameplay 640x480", 640, 480},
+	} {
+		golden := newGoldenWindow(t)
+		startGame(7)
+		playScripted(t, golden)
+		window := offscreen.New(size.width, size.height)
+		view := fitScreen(window)
+		// the time stands still, so this frame only draws the game
+		playing.update(view)
+		view.drawBars()
+		checkGolden(t, window, size.name)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// In a sprint, the zombies come at a steady rate and cannot eat you. Kill as
+// many as possible before the time runs out, every wrong answer costs time.
+var sprintDurations = []time.Duration{
+	30 * time.Second,
+	60 * time.Second,
+	120 * time.Second,
+}
+
+// sprintTable is the high score table for sprints of the given length.
+func sprintTable(d time.Duration) string {
+	return fmt.Sprintf("_sprint_%d", int(d/time.Second))
+}
+
+// clock formats a count of frames or ticks as minutes and seconds, e.g. 1:05.
+func clock(count, perSecond int) string {
+	seconds := (count + perSecond - 1) / perSecond
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
@@ -0,0 +1,52 @@
+//go:build !js
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+func loadText(name string) string {
+	data, err := os.ReadFile(dataPath(name))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// loadFolder returns the contents of all files in the folder by file name.
+func loadFolder(name string) map[string]string {
+	entries, err := os.ReadDir(dataPath(name))
+	if err != nil {
+		return nil
+	}
+	files := make(map[string]string)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if data, err := os.ReadFile(filepath.Join(dataPath(name), e.Name())); err == nil {
+			files[e.Name()] = string(data)
+		}
+	}
+	return files
+}
+
+// folderPath tells the player where to put files for loadFolder.
+func folderPath(name string) string {
+	return dataPath(name)
+}
+
+func saveText(name, text string) {
+	os.WriteFile(dataPath(name), []byte(text), 0666)
+}
+
+// saveFile writes the data to the file in the folder, the folder is created if
+// it does not exist.
+func saveFile(folder, name string, data []byte) error {
+	if err := os.MkdirAll(dataPath(folder), 0777); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dataPath(folder), name), data, 0666)
+}
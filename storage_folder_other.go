@@ -7,12 +7,12 @@ import (
 	"path/filepath"
 )
 
-func highscoresPath() string {
+func dataPath(name string) string {
 	dir := "."
 
 	if exe, err := os.Executable(); err == nil {
 		dir = filepath.Dir(exe)
 	}
 
-	return filepath.Join(dir, "brainless_jogging_highscores")
+	return filepath.Join(dir, name)
 }
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+func dataPath(name string) string {
+	return filepath.Join(os.Getenv("APPDATA"), name)
+}
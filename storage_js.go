@@ -0,0 +1,42 @@
+//go:build js
+
+package main
+
+import "syscall/js"
+
+func loadText(name string) string {
+	text := js.Global().Get("localStorage").Call("getItem", name)
+	if text.IsNull() {
+		return ""
+	}
+	return text.String()
+}
+
+// loadFolder returns nothing, there are no folders in the browser.
+func loadFolder(name string) map[string]string {
+	return nil
+}
+
+// folderPath is empty because there are no folders in the browser.
+func folderPath(name string) string {
+	return ""
+}
+
+func saveText(name, text string) {
+	js.Global().Get("localStorage").Call("setItem", name, text)
+}
+
+// saveFile lets the browser download the data, there are no folders in the
+// browser.
+func saveFile(folder, name string, data []byte) error {
+	array := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(array, data)
+	blob := js.Global().Get("Blob").New([]interface{}{array})
+	url := js.Global().Get("URL").Call("createObjectURL", blob)
+	link := js.Global().Get("document").Call("createElement", "a")
+	link.Set("href", url)
+	link.Set("download", name)
+	link.Call("click")
+	js.Global().Get("URL").Call("revokeObjectURL", url)
+	return nil
+}
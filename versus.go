@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	versusPort      = 4141
+	versusProtocol  = 1
+	versusHandshake = 5 * time.Second
+)
+
+// versusConn is the connection to the opponent in a LAN race. All messages are
+// single lines of text:
+//
+//	hello <protocol>  sent by the joining player right after connecting
+//	seed <seed>       the host's answer, both players start the match with it
+//	kill              the sender killed a zombie, the receiver gets an extra one
+//	dead              the sender was eaten, the receiver wins the match
+//
+// Closing the connection ends the match.
+type versusConn struct {
+	conn      net.Conn
+	messages  chan string
+	done      chan struct{} // closed by close, stops the reader
+	closeOnce sync.Once
+}
+
+func newVersusConn(conn net.Conn) *versusConn {
+	c := &versusConn{
+		conn:     conn,
+		messages: make(chan string, 64),
+		done:     make(chan struct{}),
+	}
+	go func() {
+		defer close(c.messages)
+		lines := bufio.NewScanner(conn)
+		for lines.Scan() {
+			select {
+			case c.messages <- lines.Text():
+			case <-c.done:
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// send writes the message to the opponent. Errors are ignored, a broken
+// connection is noticed when receiving.
+func (c *versusConn) send(msg string) {
+	fmt.Fprintln(c.conn, msg)
+}
+
+// receive returns the next message without blocking. If no message arrived,
+// msg is empty. open is false once the connection was closed.
+func (c *versusConn) receive() (msg string, open bool) {
+	select {
+	case msg, open := <-c.messages:
+		return msg, open
+	default:
+		return "", true
+	}
+}
+
+// await blocks until the next message arrives or the handshake times out.
+func (c *versusConn) await() (string, error) {
+	select {
+	case msg, open := <-c.messages:
+		if !open {
+			return "", errors.New("connection closed")
+		}
+		return msg, nil
+	case <-time.After(versusHandshake):
+		return "", errors.New("no answer from opponent")
+	}
+}
+
+// close ends the match. It can be called more than once.
+func (c *versusConn) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// hostVersus waits for an opponent to join and starts the match with the given
+// seed.
+func hostVersus(l net.Listener, seed int64) (*versusConn, error) {
+	conn, err := l.Accept()
+	if err != nil {
+		return nil, err
+	}
+	c := newVersusConn(conn)
+	msg, err := c.await()
+	if err != nil {
+		c.close()
+		return nil, err
+	}
+	if msg != fmt.Sprintf("hello %d", versusProtocol) {
+		c.close()
+		return nil, errors.New("opponent has a different game version")
+	}
+	c.send(fmt.Sprintf("seed %d", seed))
+	return c, nil
+}
+
+// joinVersus connects to the host at the given address and returns the seed
+// for the match. The address is a host name or IP, the versusPort is used
+// unless the address has a port.
+func joinVersus(address string) (*versusConn, int64, error) {
+	conn, err := net.DialTimeout("tcp", versusAddress(address), versusHandshake)
+	if err != nil {
+		return nil, 0, err
+	}
+	c := newVersusConn(conn)
+	c.send(fmt.Sprintf("hello %d", versusProtocol))
+	msg, err := c.await()
+	if err != nil {
+		c.close()
+		return nil, 0, err
+	}
+	var seed int64
+	if _, err := fmt.Sscanf(msg, "seed %d", &seed); err != nil {
+		c.close()
+		return nil, 0, errors.New("unexpected message from host: " + msg)
+	}
+	return c, seed, nil
+}
+
+func versusAddress(address string) string {
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+	return net.JoinHostPort(strings.Trim(address, "[]"), strconv.Itoa(versusPort))
+}
+
+// localAddresses lists this computer's IPv4 addresses so the host can tell them
+// to the joining player.
+func localAddresses() []string {
+	var list []string
+	addrs, _ := net.InterfaceAddrs()
+	for _, addr := range addrs {
+		if ip, ok := addr.(*net.IPNet); ok && !ip.IP.IsLoopback() && ip.IP.To4() != nil {
+			list = append(list, ip.IP.String())
+		}
+	}
+	return list
+}
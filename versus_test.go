@@ -0,0 +1,134 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestJoinedPlayerGetsTheHostsSeed(t *testing.T) {
+	host, joined, seed := startVersus(t)
+	defer host.close()
+	defer joined.close()
+	if seed != 42 {
+		t.Errorf("the joined player got seed %d instead of 42", seed)
+	}
+}
+
+func TestJoinUsesTheVersusPortByDefault(t *testing.T) {
+	for address, want := range map[string]string{
+		"localhost":      "localhost:4141",
+		"192.168.0.7":    "192.168.0.7:4141",
+		"::1":            "[::1]:4141",
+		"[::1]":          "[::1]:4141",
+		"localhost:5000": "localhost:5000",
+	} {
+		if got := versusAddress(address); got != want {
+			t.Errorf("%q is joined at %q instead of %q", address, got, want)
+		}
+	}
+}
+
+// TestRaceIsWonWhenTheOpponentIsEaten lets the host kill a zombie, which sends
+// one over, and then be eaten.
+func TestRaceIsWonWhenTheOpponentIsEaten(t *testing.T) {
+	newGoldenWindow(t)
+	host, joined, seed := startVersus(t)
+	defer host.close()
+	startVersusGame(joined, seed)
+	defer playing.leave()
+
+	zombies := len(playing.world.Zombies)
+	host.send("kill")
+	receiveUntil(t, "the opponent's kill", func() bool {
+		return len(playing.world.Zombies) > zombies
+	})
+	host.send("dead")
+	receiveUntil(t, "the opponent's death", func() bool {
+		return playing.versusOver != ""
+	})
+	if playing.versusOver != "You won the race!" {
+		t.Errorf("the race ended with %q", playing.versusOver)
+	}
+}
+
+// TestRaceIsLostWhenEatenFirst plays the scripted game, in which the joined
+// player kills zombies, and then lets them be eaten.
+func TestRaceIsLostWhenEatenFirst(t *testing.T) {
+	window := newGoldenWindow(t)
+	host, joined, seed := startVersus(t)
+	defer host.close()
+	startVersusGame(joined, seed)
+	defer playing.leave()
+
+	playScripted(t, window)
+	if msg, err := host.await(); err != nil || msg != "kill" {
+		t.Fatalf("the host got %q, %v instead of a kill", msg, err)
+	}
+	waitForDeath(t)
+	for {
+		msg, err := host.await()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg == "dead" {
+			break
+		}
+	}
+	if playing.versusOver != "You were eaten first!" {
+		t.Errorf("the race ended with %q", playing.versusOver)
+	}
+	joined.close()
+	if _, err := host.await(); err == nil {
+		t.Error("the host still receives messages after the opponent left")
+	}
+}
+
+// startVersus hosts a race on a free port and joins it.
+func startVersus(t *testing.T) (host, joined *versusConn, seed int64) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	hosted := make(chan *versusConn, 1)
+	go func() {
+		conn, err := hostVersus(l, 42)
+		if err != nil {
+			t.Error(err)
+		}
+		hosted <- conn
+	}()
+	joined, seed, err = joinVersus(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	host = <-hosted
+	if host == nil {
+		t.FailNow()
+	}
+	return host, joined, seed
+}
+
+func startVersusGame(conn *versusConn, seed int64) {
+	playing.settings = gameSettings{
+		players: 1,
+		seed:    seed,
+		versus:  conn,
+	}
+	playing.enter(lobby)
+}
+
+// receiveUntil lets the playing state read the opponent's messages until the
+// condition is met.
+func receiveUntil(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(versusHandshake)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("waiting for " + what + " timed out")
+		}
+		time.Sleep(time.Millisecond)
+		playing.receiveFromOpponent()
+	}
+}